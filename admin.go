@@ -0,0 +1,183 @@
+package main
+
+import (
+	"crypto/subtle"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Kunci API admin dari env ADMIN_API_KEY; kosong berarti tidak ada request yang dianggap admin
+var adminAPIKey string
+
+// isAdmin memeriksa header X-API-Key dengan perbandingan waktu-konstan
+func isAdmin(r *http.Request) bool {
+	if adminAPIKey == "" {
+		return false
+	}
+	key := r.Header.Get("X-API-Key")
+	return subtle.ConstantTimeCompare([]byte(key), []byte(adminAPIKey)) == 1
+}
+
+// requireAdmin membatasi handler hanya untuk request dengan kunci API admin
+func requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isAdmin(r) {
+			writeJSONError(w, http.StatusUnauthorized, "Tidak diizinkan")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// adminProduct adalah jalur serialisasi khusus admin. Product sendiri tidak pernah
+// menyerialisasi cost (json:"-"), sehingga respons publik tidak mungkin membocorkannya.
+type adminProduct struct {
+	Product
+	Cost          *Money   `json:"cost"`
+	Margin        *Money   `json:"margin"`
+	MarginPercent *float64 `json:"marginPercent"`
+}
+
+func newAdminProduct(p Product) adminProduct {
+	a := adminProduct{Product: p, Cost: p.Cost}
+	if p.Cost != nil {
+		margin := p.Price - *p.Cost
+		a.Margin = &margin
+		if p.Price != 0 {
+			percent := round2(margin.Float64() / p.Price.Float64() * 100)
+			a.MarginPercent = &percent
+		}
+	}
+	return a
+}
+
+func newAdminProducts(products []Product) []adminProduct {
+	out := make([]adminProduct, 0, len(products))
+	for _, p := range products {
+		out = append(out, newAdminProduct(p))
+	}
+	return out
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// writeAdminProducts mengirim daftar produk versi admin. Tidak pernah di-cache,
+// karena cache daftar produk hanya boleh berisi serialisasi publik.
+func writeAdminProducts(w http.ResponseWriter, products []Product, marshaller func(v interface{}) ([]byte, error)) {
+	jsonData, err := marshaller(newAdminProducts(products))
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mem-format data")
+		return
+	}
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonData)
+}
+
+// updateCostHandler (admin) mengatur harga pokok produk; {"cost": null} menghapusnya
+func (a *App) updateCostHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "id produk tidak valid")
+		return
+	}
+	var payload struct {
+		Cost *Money `json:"cost"`
+	}
+	if err := jsoni.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if payload.Cost != nil && *payload.Cost < 0 {
+		writeJSONError(w, http.StatusBadRequest, "cost tidak boleh negatif")
+		return
+	}
+	qc, cancel := withQueryTimeout(r.Context())
+	res, err := a.db.ExecContext(qc, `UPDATE products SET cost = $1 WHERE id = $2 AND deleted_at IS NULL`, payload.Cost, id)
+	cancel()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui cost")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+		return
+	}
+	a.invalidateProductCache(r.Context(), id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// updateCacheTTLHandler (admin) mengatur override TTL cache produk;
+// {"cacheTtlSeconds": null} kembali ke TTL global
+func (a *App) updateCacheTTLHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "id produk tidak valid")
+		return
+	}
+	var payload struct {
+		CacheTTLSeconds *int `json:"cacheTtlSeconds"`
+	}
+	if err := jsoni.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if payload.CacheTTLSeconds != nil && *payload.CacheTTLSeconds <= 0 {
+		writeJSONError(w, http.StatusBadRequest, "cacheTtlSeconds harus lebih dari 0")
+		return
+	}
+	qc, cancel := withQueryTimeout(r.Context())
+	res, err := a.db.ExecContext(qc, `UPDATE products SET cache_ttl_seconds = $1 WHERE id = $2 AND deleted_at IS NULL`, payload.CacheTTLSeconds, id)
+	cancel()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui TTL cache")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+		return
+	}
+	// Detail dan halaman daftar yang sudah di-cache memakai TTL lama
+	a.invalidateProductCaches(r.Context(), id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// updateLowStockThresholdHandler (admin) mengatur ambang webhook stok menipis produk;
+// {"lowStockThreshold": null} kembali ke LOW_STOCK_THRESHOLD global, 0 menonaktifkan
+func (a *App) updateLowStockThresholdHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "id produk tidak valid")
+		return
+	}
+	var payload struct {
+		LowStockThreshold *int `json:"lowStockThreshold"`
+	}
+	if err := jsoni.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if payload.LowStockThreshold != nil && *payload.LowStockThreshold < 0 {
+		writeJSONError(w, http.StatusBadRequest, "lowStockThreshold tidak boleh negatif")
+		return
+	}
+	qc, cancel := withQueryTimeout(r.Context())
+	res, err := a.db.ExecContext(qc, `UPDATE products SET low_stock_threshold = $1 WHERE id = $2 AND deleted_at IS NULL`, payload.LowStockThreshold, id)
+	cancel()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui ambang stok")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+		return
+	}
+	// Trigger menaikkan updated_at dan version, jadi detail yang di-cache ikut basi
+	a.invalidateProductCache(r.Context(), id)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"database/sql"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// App memegang dependensi satu instance server. Handler dan helper yang menyentuh database
+// atau Redis adalah method App, sehingga test dapat menyuntikkan koneksinya sendiri dan
+// dua instance dapat berjalan dalam satu proses.
+//
+// Pengaturan perilaku (TTL, batas, fitur) dan state in-memory seperti cache L1, rate limiter,
+// serta penghitung metrik masih berupa variabel paket yang dipakai bersama seluruh instance.
+type App struct {
+	cfg Config
+	db  *sql.DB
+	rdb *redis.Client
+
+	dbPool *poolMonitor
+}
+
+// newApp membuat App tanpa koneksi; db dan rdb diisi initDB dan initRedis
+func newApp(cfg Config) *App {
+	return &App{cfg: cfg, dbPool: &poolMonitor{}}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// newTestApp membuat App dengan database tiruan (sqlmock) dan Redis di memori (miniredis),
+// sehingga handler dapat diuji lewat router asli tanpa Docker. Query dicocokkan sebagai regex.
+func newTestApp(t *testing.T) (*App, sqlmock.Sqlmock, *miniredis.Miniredis) {
+	t.Helper()
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr := miniredis.RunT(t)
+	a := newApp(defaultConfig())
+	a.db = db
+	a.rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		a.rdb.Close()
+		db.Close()
+	})
+	return a, mock, mr
+}
+
+// productColumnNames mengikuti urutan productColumns
+var productColumnNames = []string{"id", "name", "price", "stock", "category", "sku", "tags", "cost", "cache_ttl_seconds",
+	"attributes", "available_stock", "created_at", "updated_at", "translations", "version", "deleted_at"}
+
+var testProductTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// productRows membangun baris hasil productColumns untuk produk sederhana tanpa varian
+func productRows(products ...Product) *sqlmock.Rows {
+	rows := sqlmock.NewRows(productColumnNames)
+	for _, p := range products {
+		rows.AddRow(p.ID, p.Name, p.Price.String(), p.Stock, p.Category, p.SKU, []byte("{}"), nil, nil,
+			[]byte("{}"), p.Stock, testProductTime, testProductTime, []byte("{}"), 1, driver.Value(nil))
+	}
+	return rows
+}
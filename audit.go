@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Riwayat perubahan stok dan harga produk (tabel audit_log). Entri selalu ditulis di
+// transaksi yang sama dengan perubahannya, jadi tidak ada perubahan tanpa jejak atau
+// jejak tanpa perubahan.
+
+// Batas jumlah entri per respons GET /products/{id}/history (?limit=)
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 500
+)
+
+// auditChange adalah satu field yang berubah; nilai disimpan sebagai teks
+type auditChange struct {
+	Field    string
+	OldValue string
+	NewValue string
+}
+
+type auditEntry struct {
+	ID        int64    `json:"id"`
+	Field     string   `json:"field"`
+	OldValue  *string  `json:"oldValue"`
+	NewValue  *string  `json:"newValue"`
+	RequestID string   `json:"requestId,omitempty"`
+	ChangedAt JSONTime `json:"changedAt"`
+}
+
+// recordAudit menulis perubahan yang nilainya benar-benar berbeda ke audit_log di dalam tx
+func recordAudit(c context.Context, tx *sql.Tx, productID int, changes ...auditChange) error {
+	requestID := requestIDFromContext(c)
+	for _, ch := range changes {
+		if ch.OldValue == ch.NewValue {
+			continue
+		}
+		_, err := tx.ExecContext(c, `INSERT INTO audit_log (product_id, field, old_value, new_value, request_id) VALUES ($1, $2, $3, $4, NULLIF($5, ''))`,
+			productID, ch.Field, ch.OldValue, ch.NewValue, requestID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func stockChange(old, new int) auditChange {
+	return auditChange{Field: "stock", OldValue: strconv.Itoa(old), NewValue: strconv.Itoa(new)}
+}
+
+// productHistoryHandler mengembalikan riwayat perubahan produk, terbaru lebih dulu.
+// Produk yang sudah dihapus (soft delete) tetap dapat dilihat riwayatnya.
+func (a *App) productHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "id produk tidak valid")
+		return
+	}
+	limit := defaultHistoryLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "limit harus bilangan bulat positif")
+			return
+		}
+		limit = min(n, maxHistoryLimit)
+	}
+
+	var exists bool
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	if err := a.db.QueryRowContext(qc, `SELECT EXISTS(SELECT 1 FROM products WHERE id=$1)`, id).Scan(&exists); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil riwayat produk")
+		return
+	}
+	if !exists {
+		writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+		return
+	}
+
+	rows, err := a.db.QueryContext(qc, `SELECT id, field, old_value, new_value, COALESCE(request_id, ''), changed_at
+		FROM audit_log WHERE product_id = $1 ORDER BY changed_at DESC, id DESC LIMIT $2`, id, limit)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil riwayat produk")
+		return
+	}
+	defer rows.Close()
+	entries := make([]auditEntry, 0)
+	for rows.Next() {
+		var e auditEntry
+		if err := rows.Scan(&e.ID, &e.Field, &e.OldValue, &e.NewValue, &e.RequestID, &e.ChangedAt.Time); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Gagal memindai riwayat produk")
+			return
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil riwayat produk")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	jsoni.NewEncoder(w).Encode(entries)
+}
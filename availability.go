@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+// Batas jumlah item per permintaan cek ketersediaan
+const maxAvailabilityItems = 200
+
+type availabilityRequestItem struct {
+	ID       int `json:"id"`
+	Quantity int `json:"quantity"`
+}
+
+type availabilityResult struct {
+	ID        int  `json:"id"`
+	Quantity  int  `json:"quantity"`
+	Found     bool `json:"found"`
+	Stock     int  `json:"stock"`
+	Available bool `json:"available"`
+}
+
+// checkAvailabilityHandler memeriksa banyak item sekaligus dengan satu query WHERE id = ANY.
+// Stok yang dibandingkan adalah stok tersedia (total varian bila produk memiliki varian).
+func (a *App) checkAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	var items []availabilityRequestItem
+	if err := decodeJSONGuarded(r, &items); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(items) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "Daftar item tidak boleh kosong")
+		return
+	}
+	if len(items) > maxAvailabilityItems {
+		writeJSONError(w, http.StatusBadRequest, "Terlalu banyak item dalam satu permintaan")
+		return
+	}
+
+	ids := make([]int, 0, len(items))
+	for _, it := range items {
+		if it.Quantity <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "quantity harus lebih dari 0")
+			return
+		}
+		ids = append(ids, it.ID)
+	}
+
+	sqlStatement := `SELECT ` + productColumns + ` FROM products p WHERE p.id = ANY($1) AND p.deleted_at IS NULL`
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	rows, err := a.db.QueryContext(qc, sqlStatement, pq.Array(ids))
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memeriksa ketersediaan")
+		return
+	}
+	defer rows.Close()
+
+	stock := map[int]int{}
+	for rows.Next() {
+		var p Product
+		if err := scanProduct(rows, &p); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Gagal memindai data produk")
+			return
+		}
+		stock[p.ID] = p.AvailableStock
+	}
+	if err := rows.Err(); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memeriksa ketersediaan")
+		return
+	}
+
+	results := make([]availabilityResult, 0, len(items))
+	for _, it := range items {
+		s, found := stock[it.ID]
+		results = append(results, availabilityResult{
+			ID:        it.ID,
+			Quantity:  it.Quantity,
+			Found:     found,
+			Stock:     s,
+			Available: found && s >= it.Quantity,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	jsoni.NewEncoder(w).Encode(results)
+}
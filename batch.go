@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/lib/pq"
+)
+
+// Batas jumlah produk per POST /products/batch
+const maxBatchItems = 1000
+
+// writeBatchItemError melaporkan item pertama yang membuat seluruh batch ditolak
+func writeBatchItemError(w http.ResponseWriter, status, index int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	jsoni.NewEncoder(w).Encode(map[string]interface{}{
+		"error": "item ke-" + strconv.Itoa(index) + ": " + msg,
+		"index": index,
+	})
+}
+
+// createProductsBatchHandler membuat banyak produk dalam satu transaksi. Semua item divalidasi
+// lebih dulu dengan aturan yang sama seperti POST /products; satu item gagal berarti seluruh
+// batch dibatalkan. Cache hanya diinvalidasi sekali setelah commit.
+func (a *App) createProductsBatchHandler(w http.ResponseWriter, r *http.Request) {
+	limitRequestBody(w, r)
+	var raw []json.RawMessage
+	if err := decodeJSONGuarded(r, &raw); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if len(raw) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "Daftar produk tidak boleh kosong")
+		return
+	}
+	if len(raw) > maxBatchItems {
+		writeJSONError(w, http.StatusBadRequest, "Maksimal "+strconv.Itoa(maxBatchItems)+" produk per batch")
+		return
+	}
+
+	products := make([]Product, len(raw))
+	attrs := make([][]byte, len(raw))
+	for i, item := range raw {
+		p, err := decodeNewProduct(bytes.NewReader(item))
+		if err != nil {
+			writeBatchItemError(w, http.StatusBadRequest, i, err.Error())
+			return
+		}
+		if err := validateProduct(p); err != nil {
+			writeBatchItemError(w, http.StatusBadRequest, i, err.Error())
+			return
+		}
+		if err := validatePrice(p.Price); err != nil {
+			writeBatchItemError(w, http.StatusUnprocessableEntity, i, err.Error())
+			return
+		}
+		if attrs[i], err = normalizeNewProduct(&p); err != nil {
+			writeBatchItemError(w, http.StatusBadRequest, i, "Atribut produk tidak valid")
+			return
+		}
+		products[i] = p
+	}
+
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	tx, err := a.db.BeginTx(qc, nil)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal membuat produk")
+		return
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(qc, `INSERT INTO products (name, price, stock, category, sku, tags, attributes) VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7) RETURNING id, created_at, updated_at, version`)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal membuat produk")
+		return
+	}
+	defer stmt.Close()
+	for i := range products {
+		p := &products[i]
+		err := stmt.QueryRowContext(qc, p.Name, p.Price, p.Stock, p.Category, p.SKU, pq.Array(p.Tags), attrs[i]).Scan(&p.ID, &p.CreatedAt.Time, &p.UpdatedAt.Time, &p.Version)
+		if err != nil {
+			if isPQError(err, pqUniqueViolation) {
+				writeBatchItemError(w, http.StatusConflict, i, "SKU sudah dipakai")
+				return
+			}
+			writeJSONError(w, http.StatusInternalServerError, "Gagal membuat produk")
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal membuat produk")
+		return
+	}
+
+	ids := make([]int, len(products))
+	categories := make([]string, len(products))
+	for i := range products {
+		p := &products[i]
+		ids[i] = p.ID
+		categories[i] = p.Category
+		// Produk baru belum memiliki varian
+		p.AvailableStock = p.Stock
+		p.Available = p.Stock > 0
+	}
+	a.invalidateManyProductCaches(r.Context(), ids, categories...)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	jsoni.NewEncoder(w).Encode(products)
+}
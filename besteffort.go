@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Batas waktu lunak untuk ?best_effort=true; setelah lewat, baris yang sudah terbaca dikirim
+var bestEffortDeadline = 2 * time.Second
+
+// fetchProductsBestEffort menjalankan query daftar produk dengan tenggat lunak.
+// Jika tenggat terlewati, baris yang sudah terpindai dikembalikan dengan partial=true.
+//
+// Catatan konsistensi: hasil parsial adalah potongan awal halaman (sesuai urutan ORDER BY),
+// bukan sampel acak, dan bisa kosong sama sekali bila query belum mengembalikan baris apa pun.
+// Hasil parsial tidak pernah disimpan ke cache dan tidak diberi ETag, sehingga klien tidak
+// boleh menganggapnya sebagai isi halaman yang sebenarnya (mis. untuk menghitung total).
+func (a *App) fetchProductsBestEffort(c context.Context, filter productFilter, limit, offset int) (products []Product, partial bool, err error) {
+	softCtx, cancel := context.WithTimeout(c, bestEffortDeadline)
+	defer cancel()
+
+	sqlStatement, args := productListQuery(filter, limit, offset)
+	products, err = a.queryProducts(softCtx, sqlStatement, args...)
+	localizeProducts(products, filter.Locales)
+	if err == nil {
+		return products, false, nil
+	}
+	// Hanya tenggat lunak yang menghasilkan respons parsial; error lain (atau klien
+	// yang memutus koneksi) tetap diperlakukan sebagai kegagalan
+	if softCtx.Err() != nil && c.Err() == nil {
+		slog.WarnContext(c, "Query daftar produk melewati tenggat, mengirim baris parsial", "deadline", bestEffortDeadline, "rows", len(products))
+		return products, true, nil
+	}
+	return nil, false, err
+}
+
+func writePartialProducts(w http.ResponseWriter, r *http.Request, products []Product, marshaller func(v interface{}) ([]byte, error)) {
+	jsonData, err := marshaller(products)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mem-format data")
+		return
+	}
+	w.Header().Del("ETag")
+	w.Header().Set("X-Partial-Result", "true")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", productContentType(r))
+	w.Write(jsonData)
+}
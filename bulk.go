@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Slot global untuk operasi bulk (import, bulk create, penyesuaian harga, bulk tag, reindex)
+// agar beberapa import bersamaan tidak menghabiskan koneksi DB dan memori untuk trafik biasa.
+var (
+	bulkSlots     = make(chan struct{}, 2)
+	bulkQueueWait = 5 * time.Second
+)
+
+// initBulkLimiter mengatur jumlah operasi bulk bersamaan (BULK_MAX_CONCURRENT) dan berapa
+// lama request berlebih menunggu slot (BULK_QUEUE_TIMEOUT; 0 = langsung ditolak)
+func initBulkLimiter(max int, wait time.Duration) {
+	if max < 1 {
+		max = 1
+	}
+	bulkSlots = make(chan struct{}, max)
+	bulkQueueWait = wait
+}
+
+// limitBulk menahan request bulk sampai ada slot kosong. Bila slot tidak didapat dalam
+// bulkQueueWait, request ditolak dengan 429 dan Retry-After.
+func limitBulk(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !acquireBulkSlot(r) {
+			retry := int(bulkQueueWait.Seconds())
+			if retry < 1 {
+				retry = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(retry))
+			writeJSONError(w, http.StatusTooManyRequests, "Terlalu banyak operasi bulk berjalan, coba lagi nanti")
+			return
+		}
+		defer func() { <-bulkSlots }()
+		next(w, r)
+	}
+}
+
+func acquireBulkSlot(r *http.Request) bool {
+	select {
+	case bulkSlots <- struct{}{}:
+		return true
+	default:
+	}
+	if bulkQueueWait <= 0 {
+		return false
+	}
+	timer := time.NewTimer(bulkQueueWait)
+	defer timer.Stop()
+	select {
+	case bulkSlots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Pola kunci cache daftar produk (semua halaman, limit, dan kombinasi filter)
+const cacheKeyProductListPattern = "products:*"
+
+// Dengan CACHE_WRITE_NX=true, cache daftar diisi memakai SET NX: saat beberapa request
+// cache-miss bersamaan, penulis pertama menang dan sisanya melewati penulisan yang sama.
+// Perbaikan cache oleh verifikasi konsistensi tetap menimpa (SET biasa).
+var cacheWriteNX = false
+
+// Penghitung penulisan cache daftar untuk /metrics, guna mengukur penghematan mode NX
+var cacheListWrites, cacheListWritesSkipped, cacheListWritesOversize uint64
+
+// Batas ukuran satu entri cache daftar (LIST_CACHE_MAX_BYTES); 0 berarti tanpa batas.
+// Halaman yang lebih besar tidak di-cache agar Redis tidak menyimpan dan mengirim blob raksasa.
+var listCacheMaxBytes = 1 << 20
+
+// setListCache menyimpan satu entri cache daftar produk sesuai mode cacheWriteNX
+func (a *App) setListCache(c context.Context, key string, value interface{}, ttl time.Duration) {
+	if data, ok := value.([]byte); ok && listCacheMaxBytes > 0 && len(data) > listCacheMaxBytes {
+		atomic.AddUint64(&cacheListWritesOversize, 1)
+		slog.Warn("Cache dilewati, ukuran melebihi batas", "key", key, "bytes", len(data), "limit", listCacheMaxBytes)
+		return
+	}
+	if !cacheWriteNX {
+		atomic.AddUint64(&cacheListWrites, 1)
+		if err := a.rdb.Set(c, key, value, ttl).Err(); err != nil {
+			slog.Error("Gagal menyimpan ke Redis", "err", err)
+		}
+		return
+	}
+	written, err := a.rdb.SetNX(c, key, value, ttl).Result()
+	if err != nil {
+		slog.Error("Gagal menyimpan ke Redis", "err", err)
+		return
+	}
+	if written {
+		atomic.AddUint64(&cacheListWrites, 1)
+	} else {
+		atomic.AddUint64(&cacheListWritesSkipped, 1)
+	}
+}
+
+func writeCacheWriteMetrics(w *strings.Builder) {
+	w.WriteString("# HELP cache_list_writes_total Penulisan cache daftar produk menurut hasil.\n")
+	w.WriteString("# TYPE cache_list_writes_total counter\n")
+	fmt.Fprintf(w, "cache_list_writes_total{result=\"written\"} %d\n", atomic.LoadUint64(&cacheListWrites))
+	fmt.Fprintf(w, "cache_list_writes_total{result=\"skipped\"} %d\n", atomic.LoadUint64(&cacheListWritesSkipped))
+	fmt.Fprintf(w, "cache_list_writes_total{result=\"oversize\"} %d\n", atomic.LoadUint64(&cacheListWritesOversize))
+}
+
+// Read-your-writes untuk daftar produk: setiap invalidasi memasang flag dengan TTL singkat
+// (READ_AFTER_WRITE_WINDOW). Selama flag ada, daftar dibaca langsung dari DB dan tidak
+// di-cache, sehingga request yang sempat mengisi ulang cache dengan data sebelum penulisan
+// tidak menyembunyikan perubahan dari klien yang baru saja menyimpan. Kuncinya di luar
+// "products:*" agar tidak ikut terhapus oleh invalidasi itu sendiri.
+const listCacheBypassKey = "listcache:bypass"
+
+var readAfterWriteWindow = 2 * time.Second
+
+func (a *App) listCacheBypassed(c context.Context) bool {
+	if readAfterWriteWindow <= 0 {
+		return false
+	}
+	n, err := a.rdb.Exists(c, listCacheBypassKey).Result()
+	return err == nil && n > 0
+}
+
+// invalidateProductListCaches menghapus semua varian cache daftar produk beserta jumlahnya.
+// Memakai SCAN (bukan KEYS) agar tidak memblokir Redis pada keyspace besar.
+func (a *App) invalidateProductListCaches(c context.Context) {
+	c = detachedContext(c)
+	// Lebih dulu dari Redis: saat Redis padam, salinan lokal tetap tidak boleh tersaji setelah penulisan
+	purgeLocalListCache()
+	if readAfterWriteWindow > 0 {
+		if err := a.rdb.Set(c, listCacheBypassKey, "1", readAfterWriteWindow).Err(); err != nil {
+			slog.Error("Gagal memasang flag read-your-writes", "err", err)
+		}
+	}
+	keys, err := a.scanKeys(c, cacheKeyProductListPattern)
+	if err != nil {
+		slog.Error("Gagal memindai kunci cache Redis", "err", err)
+		return
+	}
+	if len(keys) == 0 {
+		return
+	}
+	if err := a.rdb.Del(c, keys...).Err(); err != nil {
+		slog.Error("Gagal menghapus cache Redis", "err", err)
+	}
+}
+
+// productCacheTTL mengembalikan TTL cache detail produk: override per produk bila ada
+func productCacheTTL(p Product) time.Duration {
+	if p.CacheTTLSeconds != nil && *p.CacheTTLSeconds > 0 {
+		return time.Duration(*p.CacheTTLSeconds) * time.Second
+	}
+	return cacheTTL
+}
+
+// productListCacheTTL: satu halaman daftar tidak boleh di-cache lebih lama dari
+// TTL terpendek produk di dalamnya, agar barang yang volatil tetap segar di daftar.
+func productListCacheTTL(products []Product) time.Duration {
+	ttl := cacheTTL
+	for _, p := range products {
+		if t := productCacheTTL(p); t < ttl {
+			ttl = t
+		}
+	}
+	return ttl
+}
+
+// scanKeys mengumpulkan semua kunci yang cocok dengan pola memakai SCAN
+func (a *App) scanKeys(c context.Context, pattern string) ([]string, error) {
+	iter := a.rdb.Scan(c, 0, pattern, 100).Iterator()
+	var keys []string
+	for iter.Next(c) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+// detachedContext dipakai untuk langkah yang wajib selesai setelah penulisan ter-commit
+// (invalidasi cache, pelepasan reservasi, penyimpanan respons idempoten): pembatalan dan
+// deadline request dilepas agar klien yang memutus koneksi tidak meninggalkan cache basi,
+// tetapi nilai context (request ID untuk log) tetap terbawa.
+func detachedContext(c context.Context) context.Context {
+	return context.WithoutCancel(c)
+}
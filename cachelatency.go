@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Instrumentasi latensi Redis: setiap perintah (get, set, del, ...) dicatat dalam histogram
+// kumulatif untuk /metrics, dan sampel terbaru disimpan untuk ringkasan persentil di
+// GET /admin/cache/latency. Pipeline dicatat sebagai satu operasi "pipeline".
+
+// Batas bucket histogram dalam detik
+var cacheLatencyBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+// Sampel terbaru yang disimpan per perintah; pada trafik tinggi jendela efektif bisa lebih pendek
+const cacheLatencyMaxSamples = 2048
+
+// Rentang waktu sampel untuk ringkasan persentil (CACHE_LATENCY_WINDOW)
+var cacheLatencyWindow = 5 * time.Minute
+
+type latencySample struct {
+	at time.Time
+	d  time.Duration
+}
+
+type commandLatency struct {
+	buckets []uint64 // per bucket, dijumlahkan menjadi kumulatif saat ditulis
+	count   uint64
+	sum     time.Duration
+	samples []latencySample // ring buffer
+	next    int
+}
+
+type cacheLatencyRecorder struct {
+	mu       sync.Mutex
+	commands map[string]*commandLatency
+}
+
+var cacheLatency = &cacheLatencyRecorder{commands: map[string]*commandLatency{}}
+
+func (rec *cacheLatencyRecorder) observe(name string, d time.Duration) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	cl, ok := rec.commands[name]
+	if !ok {
+		cl = &commandLatency{buckets: make([]uint64, len(cacheLatencyBuckets))}
+		rec.commands[name] = cl
+	}
+	for i, bound := range cacheLatencyBuckets {
+		if d.Seconds() <= bound {
+			cl.buckets[i]++
+			break
+		}
+	}
+	cl.count++
+	cl.sum += d
+	s := latencySample{at: time.Now(), d: d}
+	if len(cl.samples) < cacheLatencyMaxSamples {
+		cl.samples = append(cl.samples, s)
+	} else {
+		cl.samples[cl.next] = s
+		cl.next = (cl.next + 1) % cacheLatencyMaxSamples
+	}
+}
+
+type cacheLatencySummary struct {
+	Count int     `json:"count"`
+	P50Ms float64 `json:"p50Ms"`
+	P95Ms float64 `json:"p95Ms"`
+	P99Ms float64 `json:"p99Ms"`
+	MaxMs float64 `json:"maxMs"`
+}
+
+// summary menghitung persentil dari sampel dalam jendela waktu terakhir
+func (rec *cacheLatencyRecorder) summary(window time.Duration) map[string]cacheLatencySummary {
+	cutoff := time.Now().Add(-window)
+	rec.mu.Lock()
+	recent := map[string][]time.Duration{}
+	for name, cl := range rec.commands {
+		for _, s := range cl.samples {
+			if s.at.After(cutoff) {
+				recent[name] = append(recent[name], s.d)
+			}
+		}
+	}
+	rec.mu.Unlock()
+
+	out := map[string]cacheLatencySummary{}
+	for name, ds := range recent {
+		sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+		out[name] = cacheLatencySummary{
+			Count: len(ds),
+			P50Ms: durationMs(percentile(ds, 0.50)),
+			P95Ms: durationMs(percentile(ds, 0.95)),
+			P99Ms: durationMs(percentile(ds, 0.99)),
+			MaxMs: durationMs(ds[len(ds)-1]),
+		}
+	}
+	return out
+}
+
+// percentile memakai metode nearest-rank pada data yang sudah terurut
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(float64(len(sorted))*p+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+func durationMs(d time.Duration) float64 {
+	return round2(float64(d) / float64(time.Millisecond))
+}
+
+// writePrometheus menulis histogram dalam format teks Prometheus
+func (rec *cacheLatencyRecorder) writePrometheus(w *strings.Builder) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	names := make([]string, 0, len(rec.commands))
+	for name := range rec.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w.WriteString("# HELP redis_command_duration_seconds Durasi perintah Redis.\n")
+	w.WriteString("# TYPE redis_command_duration_seconds histogram\n")
+	for _, name := range names {
+		cl := rec.commands[name]
+		var cumulative uint64
+		for i, bound := range cacheLatencyBuckets {
+			cumulative += cl.buckets[i]
+			fmt.Fprintf(w, "redis_command_duration_seconds_bucket{command=%q,le=\"%g\"} %d\n", name, bound, cumulative)
+		}
+		fmt.Fprintf(w, "redis_command_duration_seconds_bucket{command=%q,le=\"+Inf\"} %d\n", name, cl.count)
+		fmt.Fprintf(w, "redis_command_duration_seconds_sum{command=%q} %g\n", name, cl.sum.Seconds())
+		fmt.Fprintf(w, "redis_command_duration_seconds_count{command=%q} %d\n", name, cl.count)
+	}
+}
+
+type cacheLatencyStartKey struct{}
+
+// cacheLatencyHook dipasang pada client Redis untuk mengukur setiap perintah
+type cacheLatencyHook struct{}
+
+func (cacheLatencyHook) BeforeProcess(c context.Context, cmd redis.Cmder) (context.Context, error) {
+	return context.WithValue(c, cacheLatencyStartKey{}, time.Now()), nil
+}
+
+func (cacheLatencyHook) AfterProcess(c context.Context, cmd redis.Cmder) error {
+	if start, ok := c.Value(cacheLatencyStartKey{}).(time.Time); ok {
+		cacheLatency.observe(cmd.Name(), time.Since(start))
+	}
+	return nil
+}
+
+func (cacheLatencyHook) BeforeProcessPipeline(c context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return context.WithValue(c, cacheLatencyStartKey{}, time.Now()), nil
+}
+
+func (cacheLatencyHook) AfterProcessPipeline(c context.Context, cmds []redis.Cmder) error {
+	if start, ok := c.Value(cacheLatencyStartKey{}).(time.Time); ok {
+		cacheLatency.observe("pipeline", time.Since(start))
+	}
+	return nil
+}
+
+// cacheLatencyHandler mengembalikan ringkasan p50/p95/p99 per perintah Redis
+func cacheLatencyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	jsoni.NewEncoder(w).Encode(map[string]interface{}{
+		"window":   cacheLatencyWindow.String(),
+		"commands": cacheLatency.summary(cacheLatencyWindow),
+	})
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/lib/pq"
+)
+
+// Batas produk yang dirinci pada pratinjau; di atas itu hanya dilaporkan sebagai truncated
+const maxPreviewProducts = 1000
+
+type cachePreviewRequest struct {
+	IDs        []int             `json:"ids,omitempty"`
+	Category   string            `json:"category,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Search     string            `json:"q,omitempty"`
+}
+
+type cachePreviewKey struct {
+	Key    string `json:"key"`
+	Kind   string `json:"kind"` // list, item, category
+	Exists bool   `json:"exists"`
+}
+
+type cachePreviewResponse struct {
+	Products  int               `json:"products"`
+	Truncated bool              `json:"truncated"`
+	Keys      []cachePreviewKey `json:"keys"`
+}
+
+// previewCacheInvalidationHandler menghitung kunci cache yang akan dihapus oleh operasi bulk
+// atas produk yang dipilih (lewat ids atau filter), tanpa menghapus apa pun.
+func (a *App) previewCacheInvalidationHandler(w http.ResponseWriter, r *http.Request) {
+	var req cachePreviewRequest
+	if err := decodeJSONGuarded(r, &req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(req.IDs) == 0 && req.Category == "" && len(req.Attributes) == 0 && req.Search == "" {
+		writeJSONError(w, http.StatusBadRequest, "ids atau filter (category, attributes, q) wajib diisi")
+		return
+	}
+
+	filter := productFilter{Attributes: req.Attributes, Search: req.Search}
+	where, args := filter.where(nil)
+	conds := where
+	and := func(cond string) {
+		if conds == "" {
+			conds = " WHERE " + cond
+		} else {
+			conds += " AND " + cond
+		}
+	}
+	if len(req.IDs) > 0 {
+		args = append(args, pq.Array(req.IDs))
+		and("p.id = ANY($" + strconv.Itoa(len(args)) + ")")
+	}
+	if req.Category != "" {
+		args = append(args, req.Category)
+		and("p.category = $" + strconv.Itoa(len(args)))
+	}
+	and("p.deleted_at IS NULL")
+	args = append(args, maxPreviewProducts+1)
+	sqlStatement := `SELECT p.id, p.category FROM products p` + conds + ` ORDER BY p.id LIMIT $` + strconv.Itoa(len(args))
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	rows, err := a.db.QueryContext(qc, sqlStatement, args...)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil produk")
+		return
+	}
+	defer rows.Close()
+
+	var resp cachePreviewResponse
+	categories := map[string]bool{}
+	var itemKeys []string
+	for rows.Next() {
+		var id int
+		var category string
+		if err := rows.Scan(&id, &category); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Gagal memindai data produk")
+			return
+		}
+		if resp.Products == maxPreviewProducts {
+			resp.Truncated = true
+			break
+		}
+		resp.Products++
+		itemKeys = append(itemKeys, productCacheKey(id))
+		categories[category] = true
+	}
+	if err := rows.Err(); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil produk")
+		return
+	}
+
+	// Semua cache daftar ikut terhapus oleh invalidateProductListCaches
+	listKeys, err := a.scanKeys(r.Context(), cacheKeyProductListPattern)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memindai kunci cache")
+		return
+	}
+	sort.Strings(listKeys)
+	resp.Keys = make([]cachePreviewKey, 0, len(listKeys)+len(itemKeys)+2*len(categories))
+	for _, k := range listKeys {
+		resp.Keys = append(resp.Keys, cachePreviewKey{Key: k, Kind: "list", Exists: true})
+	}
+
+	var candidates []cachePreviewKey
+	for _, k := range itemKeys {
+		candidates = append(candidates, cachePreviewKey{Key: k, Kind: "item"})
+	}
+	names := make([]string, 0, len(categories))
+	for c := range categories {
+		names = append(names, c)
+	}
+	sort.Strings(names)
+	for _, c := range names {
+		candidates = append(candidates,
+			cachePreviewKey{Key: categoryStockCacheKey(c), Kind: "category"},
+			cachePreviewKey{Key: categoryPriceStatsCacheKey(c), Kind: "category"})
+	}
+	if len(candidates) > 0 {
+		pipe := a.rdb.Pipeline()
+		cmds := make([]*redis.IntCmd, len(candidates))
+		for i, k := range candidates {
+			cmds[i] = pipe.Exists(r.Context(), k.Key)
+		}
+		if _, err := pipe.Exec(r.Context()); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Gagal memeriksa kunci cache")
+			return
+		}
+		for i := range candidates {
+			candidates[i].Exists = cmds[i].Val() > 0
+		}
+	}
+	resp.Keys = append(resp.Keys, candidates...)
+
+	w.Header().Set("Content-Type", "application/json")
+	jsoni.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const defaultCategory = "uncategorized"
+
+func categoryStockCacheKey(category string) string {
+	return "category:" + category + ":stock"
+}
+
+type categoryProductStock struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Stock int    `json:"stock"`
+}
+
+type categoryStock struct {
+	Category string                 `json:"category"`
+	Total    int                    `json:"total"`
+	Products []categoryProductStock `json:"products"`
+}
+
+// categoryStockHandler mengembalikan total stok dan stok per produk dalam satu kategori.
+// Stok yang dilaporkan adalah stok tersedia (total varian bila produk memiliki varian).
+func (a *App) categoryStockHandler(w http.ResponseWriter, r *http.Request) {
+	category := mux.Vars(r)["category"]
+	cacheKey := categoryStockCacheKey(category)
+
+	if cached, err := a.rdb.Get(r.Context(), cacheKey).Result(); err == nil {
+		slog.DebugContext(r.Context(), "CACHE HIT: Mengambil dari Redis", "key", cacheKey)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(cached))
+		return
+	}
+
+	slog.DebugContext(r.Context(), "CACHE MISS: Mengambil dari PostgreSQL", "key", cacheKey)
+	sqlStatement := `SELECT ` + productColumns + ` FROM products p WHERE p.category = $1 AND p.deleted_at IS NULL ORDER BY p.id`
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	rows, err := a.db.QueryContext(qc, sqlStatement, category)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil stok kategori")
+		return
+	}
+	defer rows.Close()
+
+	result := categoryStock{Category: category, Products: make([]categoryProductStock, 0)}
+	for rows.Next() {
+		var p Product
+		if err := scanProduct(rows, &p); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Gagal memindai data produk")
+			return
+		}
+		result.Total += p.AvailableStock
+		result.Products = append(result.Products, categoryProductStock{ID: p.ID, Name: p.Name, Stock: p.AvailableStock})
+	}
+	if err := rows.Err(); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil stok kategori")
+		return
+	}
+
+	jsonData, err := jsoni.Marshal(result)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mem-format data untuk cache")
+		return
+	}
+	if err := a.rdb.Set(r.Context(), cacheKey, jsonData, cacheTTL).Err(); err != nil {
+		slog.ErrorContext(r.Context(), "Gagal menyimpan ke Redis", "err", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonData)
+}
+
+// invalidateCategoryStock dipanggil setiap kali stok produk dalam kategori berubah
+func (a *App) invalidateCategoryStock(c context.Context, category string) {
+	if err := a.rdb.Del(detachedContext(c), categoryStockCacheKey(category)).Err(); err != nil {
+		slog.Error("Gagal menghapus cache Redis", "err", err)
+	}
+}
+
+// invalidateCategoryStockForProduct mencari kategori produk lalu menghapus cache stoknya
+func (a *App) invalidateCategoryStockForProduct(c context.Context, productID int) {
+	var category string
+	qc, cancel := withQueryTimeout(c)
+	defer cancel()
+	if err := a.db.QueryRowContext(qc, `SELECT category FROM products WHERE id = $1`, productID).Scan(&category); err != nil {
+		slog.ErrorContext(c, "Gagal membaca kategori produk untuk invalidasi cache", "product_id", productID, "err", err)
+		return
+	}
+	a.invalidateCategoryStock(c, category)
+}
+
+// Batas atas ?per_category= pada daftar produk per kategori
+const maxPerCategory = 100
+
+// groupedProductsHandler mengembalikan produk dikelompokkan per kategori,
+// mis. {"shoes":[...],"shirts":[...]}, dalam satu query dengan ROW_NUMBER().
+// Tanpa ?per_category= semua produk dikembalikan.
+func (a *App) groupedProductsHandler(w http.ResponseWriter, r *http.Request) {
+	perCategory := 0
+	if raw := r.URL.Query().Get("per_category"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "per_category harus bilangan bulat positif")
+			return
+		}
+		if n > maxPerCategory {
+			n = maxPerCategory
+		}
+		perCategory = n
+	}
+
+	cacheKey := fmt.Sprintf("products:grouped:per_category=%d", perCategory)
+	if cached, err := a.rdb.Get(r.Context(), cacheKey).Result(); err == nil {
+		slog.DebugContext(r.Context(), "CACHE HIT: Mengambil dari Redis", "key", cacheKey)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(cached))
+		return
+	}
+
+	slog.DebugContext(r.Context(), "CACHE MISS: Mengambil dari PostgreSQL", "key", cacheKey)
+	sqlStatement := `SELECT ` + productColumns + ` FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY category ORDER BY id) AS rn FROM products WHERE deleted_at IS NULL
+		) p`
+	var args []interface{}
+	if perCategory > 0 {
+		sqlStatement += ` WHERE p.rn <= $1`
+		args = append(args, perCategory)
+	}
+	sqlStatement += ` ORDER BY p.category, p.rn`
+	products, err := a.queryProducts(r.Context(), sqlStatement, args...)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	grouped := map[string][]Product{}
+	for _, p := range products {
+		grouped[p.Category] = append(grouped[p.Category], p)
+	}
+	jsonData, err := jsoni.Marshal(grouped) // kunci map diurutkan, sehingga byte cache stabil
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mem-format data untuk cache")
+		return
+	}
+	a.setListCache(r.Context(), cacheKey, jsonData, productListCacheTTL(products))
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonData)
+}
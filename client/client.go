@@ -0,0 +1,158 @@
+// Package client adalah klien HTTP bertipe untuk layanan produk ping-pong, supaya layanan
+// lain tidak perlu menulis ulang http.Client dan struct respons masing-masing.
+//
+// Timestamp dibaca dalam format RFC 3339, yaitu TIME_FORMAT default server.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Product adalah representasi publik satu produk seperti yang dikirim server
+type Product struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Harga dibiarkan sebagai angka desimal apa adanya (mis. "19.99") agar tidak terkena pembulatan float
+	Price          json.Number            `json:"price"`
+	Stock          int                    `json:"stock"`
+	Category       string                 `json:"category"`
+	SKU            string                 `json:"sku,omitempty"`
+	Tags           []string               `json:"tags"`
+	Attributes     map[string]interface{} `json:"attributes"`
+	AvailableStock int                    `json:"availableStock"`
+	Available      bool                   `json:"available"`
+	Description    string                 `json:"description,omitempty"`
+	Locale         string                 `json:"locale,omitempty"`
+	CreatedAt      time.Time              `json:"createdAt"`
+	UpdatedAt      time.Time              `json:"updatedAt"`
+	Version        int                    `json:"version"`
+}
+
+// NewProduct adalah body POST /products. Field kosong tidak dikirim sehingga server
+// mengisi default-nya; server menolak field yang tidak dikenal.
+type NewProduct struct {
+	Name       string                 `json:"name"`
+	Price      json.Number            `json:"price"`
+	Stock      *int                   `json:"stock,omitempty"`
+	Category   string                 `json:"category,omitempty"`
+	SKU        string                 `json:"sku,omitempty"`
+	Tags       []string               `json:"tags,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// APIError dikembalikan untuk setiap respons non-2xx
+type APIError struct {
+	StatusCode int
+	// Isi field "error" dari body, atau status teks bila body bukan JSON error
+	Message string
+	// X-Request-ID respons, untuk dicocokkan dengan log server
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("ping-pong: %d %s", e.StatusCode, e.Message)
+}
+
+// Client aman dipakai bersamaan dari banyak goroutine
+type Client struct {
+	baseURL string
+	// HTTPClient boleh diganti (mis. untuk timeout atau transport sendiri) sebelum dipakai
+	HTTPClient *http.Client
+}
+
+// NewClient membuat klien untuk baseURL seperti "http://ping-pong:8080"
+func NewClient(baseURL string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ListProducts mengambil halaman pertama daftar produk (ukuran halaman default server).
+// Server tidak memasang GET /products; daftar dilayani /products-standard.
+func (c *Client) ListProducts(ctx context.Context) ([]Product, error) {
+	var products []Product
+	if err := c.do(ctx, http.MethodGet, "/products-standard", nil, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// GetProduct mengambil satu produk; produk yang tidak ada menghasilkan *APIError berstatus 404
+func (c *Client) GetProduct(ctx context.Context, id int) (Product, error) {
+	var p Product
+	err := c.do(ctx, http.MethodGet, "/products/"+strconv.Itoa(id), nil, &p)
+	return p, err
+}
+
+// CreateProduct membuat produk dan mengembalikan produk seperti yang tersimpan di server
+func (c *Client) CreateProduct(ctx context.Context, p NewProduct) (Product, error) {
+	var created Product
+	err := c.do(ctx, http.MethodPost, "/products", p, &created)
+	return created, err
+}
+
+// UpdateStock menyetel stok absolut produk
+func (c *Client) UpdateStock(ctx context.Context, id int, stock int) error {
+	body := struct {
+		Stock int `json:"stock"`
+	}{stock}
+	return c.do(ctx, http.MethodPut, "/products/"+strconv.Itoa(id)+"/stock", body, nil)
+}
+
+// do mengirim request JSON dan, bila out tidak nil, men-decode body respons 2xx ke out.
+// Pembatalan ctx membatalkan request yang sedang berjalan.
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newAPIError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    http.StatusText(resp.StatusCode),
+		RequestID:  resp.Header.Get("X-Request-ID"),
+	}
+	var payload struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&payload); err == nil && payload.Error != "" {
+		apiErr.Message = payload.Error
+	}
+	return apiErr
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ping-pong/client"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestClientAgainstRouter memastikan setiap method client memanggil rute yang benar-benar
+// terpasang di newRouter (bukan hanya rute yang diasumsikan)
+func TestClientAgainstRouter(t *testing.T) {
+	a, mock, _ := newTestApp(t)
+	srv := httptest.NewServer(a.newRouter(trailingSlashIgnore, false))
+	defer srv.Close()
+	c := client.NewClient(srv.URL)
+	ctx := context.Background()
+
+	mock.ExpectQuery(`SELECT .* FROM products p WHERE p.deleted_at IS NULL ORDER BY p.id LIMIT \$1 OFFSET \$2`).
+		WithArgs(defaultListLimit, 0).
+		WillReturnRows(productRows(Product{ID: 1, Name: "Bola", Price: 1999, Stock: 4, Category: "alat"}))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM products p`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	products, err := c.ListProducts(ctx)
+	if err != nil {
+		t.Fatalf("ListProducts: %v", err)
+	}
+	if len(products) != 1 || products[0].Name != "Bola" || products[0].Price.String() != "19.99" {
+		t.Fatalf("ListProducts = %+v", products)
+	}
+
+	mock.ExpectQuery(`SELECT .* FROM products p WHERE p.id=\$1 AND p.deleted_at IS NULL`).
+		WithArgs(1).
+		WillReturnRows(productRows(Product{ID: 1, Name: "Bola", Price: 1999, Stock: 4, Category: "alat"}))
+	p, err := c.GetProduct(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetProduct: %v", err)
+	}
+	if p.ID != 1 || p.Stock != 4 || !p.CreatedAt.Equal(testProductTime) {
+		t.Fatalf("GetProduct = %+v", p)
+	}
+
+	mock.ExpectQuery(`SELECT .* FROM products p WHERE p.id=\$1 AND p.deleted_at IS NULL`).
+		WithArgs(2).
+		WillReturnRows(productRows())
+	_, err = c.GetProduct(ctx, 2)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.RequestID == "" {
+		t.Fatalf("GetProduct produk tidak ada: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Batas jumlah produk yang dapat dibandingkan dalam satu permintaan
+var maxCompareItems = 4
+
+type compareResponse struct {
+	Products []Product `json:"products"`
+	// Nama field -> nilai per produk (urutan sama dengan Products), hanya field yang berbeda
+	Differences SortedMap `json:"differences"`
+}
+
+// parseIDList membaca daftar id dipisah koma, mis. "1,2,3"
+func parseIDList(raw string) ([]int, error) {
+	var ids []int
+	seen := map[int]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.Atoi(part)
+		if err != nil || id <= 0 {
+			return nil, strconv.ErrSyntax
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (a *App) compareProductsHandler(w http.ResponseWriter, r *http.Request) {
+	ids, err := parseIDList(r.URL.Query().Get("ids"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Parameter ids harus berupa daftar angka dipisah koma")
+		return
+	}
+	if len(ids) < 2 {
+		writeJSONError(w, http.StatusBadRequest, "Minimal dua produk berbeda untuk dibandingkan")
+		return
+	}
+	if len(ids) > maxCompareItems {
+		writeJSONError(w, http.StatusBadRequest, "Maksimal "+strconv.Itoa(maxCompareItems)+" produk dapat dibandingkan")
+		return
+	}
+
+	sqlStatement := `SELECT ` + productColumns + ` FROM products p WHERE p.id = ANY($1) AND p.deleted_at IS NULL`
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	rows, err := a.db.QueryContext(qc, sqlStatement, pq.Array(ids))
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil produk")
+		return
+	}
+	defer rows.Close()
+
+	byID := map[int]Product{}
+	for rows.Next() {
+		var p Product
+		if err := scanProduct(rows, &p); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Gagal memindai data produk")
+			return
+		}
+		byID[p.ID] = p
+	}
+	if err := rows.Err(); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil produk")
+		return
+	}
+
+	// Pertahankan urutan sesuai parameter ids
+	resp := compareResponse{Products: make([]Product, 0, len(ids))}
+	for _, id := range ids {
+		p, ok := byID[id]
+		if !ok {
+			writeJSONError(w, http.StatusNotFound, "Produk dengan id "+strconv.Itoa(id)+" tidak ditemukan")
+			return
+		}
+		resp.Products = append(resp.Products, p)
+	}
+
+	resp.Differences, err = productDifferences(resp.Products)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal membandingkan produk")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	jsoni.NewEncoder(w).Encode(resp)
+}
+
+// productDifferences membandingkan representasi JSON tiap produk sehingga field baru
+// pada Product otomatis ikut dibandingkan. Field "id", "createdAt", "updatedAt", dan "version" bukan atribut produk dan dilewati.
+func productDifferences(products []Product) (SortedMap, error) {
+	fields := make([]map[string]interface{}, len(products))
+	for i, p := range products {
+		b, err := jsoni.Marshal(p)
+		if err != nil {
+			return nil, err
+		}
+		if err := jsoni.Unmarshal(b, &fields[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	diff := SortedMap{}
+	for key := range fields[0] {
+		if key == "id" || key == "createdAt" || key == "updatedAt" || key == "version" {
+			continue
+		}
+		values := make([]interface{}, len(fields))
+		differs := false
+		for i, f := range fields {
+			values[i] = f[key]
+			if !reflect.DeepEqual(f[key], fields[0][key]) {
+				differs = true
+			}
+		}
+		if differs {
+			diff[key] = values
+		}
+	}
+	return diff, nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Kompresi gzip respons (GZIP_ENABLED, default aktif). Respons di bawah gzipMinBytes
+// (GZIP_MIN_BYTES) dikirim apa adanya karena overhead gzip tidak sebanding.
+// Handler yang sudah memegang payload terkompresi (cache daftar ":gz") cukup
+// memasang Content-Encoding sendiri; middleware meneruskannya tanpa kompresi ulang.
+var (
+	gzipEnabled  = true
+	gzipMinBytes = 1024
+)
+
+var gzipWriterPool = sync.Pool{New: func() interface{} { return gzip.NewWriter(nil) }}
+
+// acceptsGzip memeriksa Accept-Encoding klien, termasuk "gzip;q=0" sebagai penolakan
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipBytes mengompresi payload sekali, mis. untuk disimpan berdampingan di cache
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzipWriterPool.Get().(*gzip.Writer)
+	defer gzipWriterPool.Put(gz)
+	gz.Reset(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gzipResponseWriter menahan body sampai gzipMinBytes sebelum memutuskan kompresi,
+// sehingga Content-Encoding dan status baru dikirim setelah keputusan diambil
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status   int
+	buf      []byte
+	decided  bool
+	gz       *gzip.Writer
+	minBytes int
+}
+
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	if g.decided || g.status != 0 {
+		return
+	}
+	g.status = status
+	// Respons tanpa body tidak perlu ditahan
+	if status == http.StatusNoContent || status == http.StatusNotModified || status < 200 {
+		g.decide(false)
+	}
+}
+
+func (g *gzipResponseWriter) Write(p []byte) (int, error) {
+	if g.decided {
+		if g.gz != nil {
+			return g.gz.Write(p)
+		}
+		return g.ResponseWriter.Write(p)
+	}
+	g.buf = append(g.buf, p...)
+	if len(g.buf) >= g.minBytes {
+		if err := g.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// decide mengirim header lalu body yang tertahan, terkompresi atau tidak
+func (g *gzipResponseWriter) decide(compress bool) error {
+	g.decided = true
+	h := g.ResponseWriter.Header()
+	if compress && h.Get("Content-Encoding") == "" {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		g.gz = gzipWriterPool.Get().(*gzip.Writer)
+		g.gz.Reset(g.ResponseWriter)
+	}
+	if g.status == 0 {
+		g.status = http.StatusOK
+	}
+	g.ResponseWriter.WriteHeader(g.status)
+	if len(g.buf) == 0 {
+		return nil
+	}
+	buf := g.buf
+	g.buf = nil
+	var err error
+	if g.gz != nil {
+		_, err = g.gz.Write(buf)
+	} else {
+		_, err = g.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// Flush pada stream (mis. ekspor NDJSON) memutuskan kompresi tanpa menunggu ambang
+func (g *gzipResponseWriter) Flush() {
+	if !g.decided {
+		g.decide(len(g.buf) > 0)
+	}
+	if g.gz != nil {
+		g.gz.Flush()
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (g *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+func (g *gzipResponseWriter) close() {
+	if !g.decided {
+		g.decide(false)
+	}
+	if g.gz != nil {
+		g.gz.Close()
+		gzipWriterPool.Put(g.gz)
+		g.gz = nil
+	}
+}
+
+// gzipMiddleware mengompresi respons untuk klien yang mendukung gzip
+func gzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipResponseWriter{ResponseWriter: w, minBytes: gzipMinBytes}
+		next.ServeHTTP(gw, r)
+		// Sengaja tanpa defer: saat panic, body tertahan tidak boleh terkirim sebagai 200
+		// sebelum recoverMiddleware menulis 500
+		gw.close()
+	})
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// getEnv mengembalikan nilai env atau nilai default jika kosong
+func getEnv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// getEnvBool membaca env bertipe boolean ("true", "1", "yes", dst.)
+func getEnvBool(key string, def bool) bool {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		switch strings.ToLower(v) {
+		case "yes", "on":
+			return true
+		case "no", "off":
+			return false
+		}
+		return def
+	}
+	return b
+}
+
+// getEnvInt membaca env bertipe integer, kembali ke default jika tidak valid
+func getEnvInt(key string, def int) int {
+	v, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key)))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// getEnvDuration membaca env dalam format time.ParseDuration (mis. "100ms", "15s")
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(strings.TrimSpace(os.Getenv(key)))
+	if err != nil || d < 0 {
+		return def
+	}
+	return d
+}
+
+// getEnvSeconds membaca env berupa jumlah detik (bilangan bulat)
+func getEnvSeconds(key string, def time.Duration) time.Duration {
+	v, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key)))
+	if err != nil || v < 0 {
+		return def
+	}
+	return time.Duration(v) * time.Second
+}
+
+// getEnvFloat membaca env bertipe float, kembali ke default jika tidak valid
+func getEnvFloat(key string, def float64) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv(key)), 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// Config adalah konfigurasi inti layanan. Nilai dibaca dari file JSON di CONFIG_FILE (opsional),
+// lalu env var dengan nama yang sama seperti sebelumnya menimpa nilai dari file, sehingga
+// deployment yang hanya memakai env tetap berjalan tanpa perubahan.
+// Pengaturan lain (fitur, batas, cache) masih dibaca langsung dari env di main.
+type Config struct {
+	DatabaseURL   string `json:"databaseUrl"`   // DATABASE_URL
+	RedisURL      string `json:"redisUrl"`      // REDIS_URL
+	RedisPassword string `json:"redisPassword"` // REDIS_PASSWORD
+	RedisDB       int    `json:"redisDb"`       // REDIS_DB
+	ListenAddr    string `json:"listenAddr"`    // LISTEN_ADDR
+
+	CacheTTLSeconds int `json:"cacheTtlSeconds"` // CACHE_TTL_SECONDS
+
+	DBMaxOpenConns           int `json:"dbMaxOpenConns"`           // DB_MAX_OPEN_CONNS
+	DBMaxIdleConns           int `json:"dbMaxIdleConns"`           // DB_MAX_IDLE_CONNS
+	DBConnMaxLifetimeMinutes int `json:"dbConnMaxLifetimeMinutes"` // DB_CONN_MAX_LIFETIME_MINUTES
+}
+
+func defaultConfig() Config {
+	return Config{
+		ListenAddr:               ":8080",
+		CacheTTLSeconds:          int(cacheTTL / time.Second),
+		DBMaxOpenConns:           25,
+		DBMaxIdleConns:           5,
+		DBConnMaxLifetimeMinutes: 30,
+	}
+}
+
+// LoadConfig membaca CONFIG_FILE dan env lalu memvalidasi hasilnya. Semua masalah dilaporkan
+// sekaligus agar bisa diperbaiki dalam satu kali jalan.
+func LoadConfig() (Config, error) {
+	return loadConfig(true)
+}
+
+// loadConfig dengan requireRedis=false dipakai mode -migrate, yang hanya butuh database
+func loadConfig(requireRedis bool) (Config, error) {
+	cfg := defaultConfig()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := readConfigFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+	cfg.DatabaseURL = getEnv("DATABASE_URL", cfg.DatabaseURL)
+	cfg.RedisURL = getEnv("REDIS_URL", cfg.RedisURL)
+	cfg.RedisPassword = getEnv("REDIS_PASSWORD", cfg.RedisPassword)
+	cfg.ListenAddr = getEnv("LISTEN_ADDR", cfg.ListenAddr)
+	// Berbeda dengan getEnvInt, nilai yang salah ketik dilaporkan alih-alih diam-diam diganti default
+	var parseErrs []error
+	for _, f := range []struct {
+		key string
+		dst *int
+	}{
+		{"REDIS_DB", &cfg.RedisDB},
+		{"CACHE_TTL_SECONDS", &cfg.CacheTTLSeconds},
+		{"DB_MAX_OPEN_CONNS", &cfg.DBMaxOpenConns},
+		{"DB_MAX_IDLE_CONNS", &cfg.DBMaxIdleConns},
+		{"DB_CONN_MAX_LIFETIME_MINUTES", &cfg.DBConnMaxLifetimeMinutes},
+	} {
+		if err := parseEnvInt(f.key, f.dst); err != nil {
+			parseErrs = append(parseErrs, err)
+		}
+	}
+	return cfg, cfg.validate(requireRedis, parseErrs...)
+}
+
+// parseEnvInt menimpa dst dengan env key bila diisi; nilai yang bukan bilangan bulat menjadi error
+func parseEnvInt(key string, dst *int) error {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("%s harus bilangan bulat, bukan %q", key, v)
+	}
+	*dst = n
+	return nil
+}
+
+// readConfigFile menimpa cfg dengan isi file; field yang salah ketik ditolak
+func readConfigFile(path string, cfg *Config) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("CONFIG_FILE: %w", err)
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("CONFIG_FILE %s tidak valid: %w", path, err)
+	}
+	return nil
+}
+
+// validate memeriksa nilai akhir config; parseErrs (env yang gagal di-parse) ikut dilaporkan
+func (c Config) validate(requireRedis bool, parseErrs ...error) error {
+	errs := append([]error{}, parseErrs...)
+	if c.DatabaseURL == "" {
+		errs = append(errs, errors.New("DATABASE_URL (databaseUrl) wajib diisi"))
+	}
+	if requireRedis && c.RedisURL == "" {
+		errs = append(errs, errors.New("REDIS_URL (redisUrl) wajib diisi"))
+	}
+	if c.ListenAddr == "" {
+		errs = append(errs, errors.New("LISTEN_ADDR (listenAddr) tidak boleh kosong"))
+	}
+	// TTL 0 berarti "tanpa kedaluwarsa" bagi Redis, jadi hanya nilai positif yang diterima
+	if c.CacheTTLSeconds <= 0 {
+		errs = append(errs, errors.New("CACHE_TTL_SECONDS (cacheTtlSeconds) harus lebih dari 0"))
+	}
+	if c.DBMaxOpenConns <= 0 {
+		errs = append(errs, errors.New("DB_MAX_OPEN_CONNS (dbMaxOpenConns) harus lebih dari 0"))
+	}
+	if c.DBMaxIdleConns < 0 || c.DBMaxIdleConns > c.DBMaxOpenConns {
+		errs = append(errs, errors.New("DB_MAX_IDLE_CONNS (dbMaxIdleConns) harus antara 0 dan DB_MAX_OPEN_CONNS"))
+	}
+	if c.RedisDB < 0 {
+		errs = append(errs, errors.New("REDIS_DB (redisDb) tidak boleh negatif"))
+	}
+	if c.DBConnMaxLifetimeMinutes < 0 {
+		errs = append(errs, errors.New("DB_CONN_MAX_LIFETIME_MINUTES (dbConnMaxLifetimeMinutes) tidak boleh negatif"))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     map[string]string
+		wantErr []string // potongan pesan yang wajib muncul; kosong berarti tidak ada error
+		check   func(t *testing.T, cfg Config)
+	}{
+		{
+			name: "env menimpa file",
+			file: `{"databaseUrl":"postgres://file","redisUrl":"file:6379","redisDb":2,"cacheTtlSeconds":30}`,
+			env:  map[string]string{"REDIS_URL": "env:6379", "REDIS_PASSWORD": "rahasia", "CACHE_TTL_SECONDS": "90"},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.DatabaseURL != "postgres://file" || cfg.RedisURL != "env:6379" || cfg.RedisPassword != "rahasia" ||
+					cfg.RedisDB != 2 || cfg.CacheTTLSeconds != 90 {
+					t.Fatalf("config tidak sesuai: %+v", cfg)
+				}
+			},
+		},
+		{
+			name:    "integer salah ketik dilaporkan",
+			env:     map[string]string{"DATABASE_URL": "postgres://x", "REDIS_URL": "r:6379", "CACHE_TTL_SECONDS": "abc", "DB_MAX_OPEN_CONNS": "ten", "REDIS_DB": "satu"},
+			wantErr: []string{`CACHE_TTL_SECONDS harus bilangan bulat, bukan "abc"`, `DB_MAX_OPEN_CONNS harus bilangan bulat, bukan "ten"`, `REDIS_DB harus bilangan bulat`},
+		},
+		{
+			name:    "wajib dan batas dilaporkan sekaligus",
+			env:     map[string]string{"DB_MAX_OPEN_CONNS": "0", "REDIS_DB": "-1"},
+			wantErr: []string{"DATABASE_URL", "REDIS_URL", "DB_MAX_OPEN_CONNS (dbMaxOpenConns)", "REDIS_DB (redisDb)"},
+		},
+	}
+	keys := []string{"CONFIG_FILE", "DATABASE_URL", "REDIS_URL", "REDIS_PASSWORD", "REDIS_DB", "LISTEN_ADDR",
+		"CACHE_TTL_SECONDS", "DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS", "DB_CONN_MAX_LIFETIME_MINUTES"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range keys {
+				t.Setenv(k, "")
+			}
+			if tt.file != "" {
+				path := filepath.Join(t.TempDir(), "config.json")
+				if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				t.Setenv("CONFIG_FILE", path)
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := LoadConfig()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("error tak terduga: %v", err)
+				}
+				tt.check(t, cfg)
+				return
+			}
+			if err == nil {
+				t.Fatal("ingin error, dapat nil")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error tidak menyebut %q:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Proporsi cache hit (0..1) yang diperiksa ulang ke database di background.
+// Default 0 (nonaktif); ?verify=true selalu memeriksa secara sinkron.
+var cacheVerifySampleRate = 0.0
+
+const cacheVerifyTimeout = 5 * time.Second
+
+// Pemeriksaan background yang masih berjalan, dikuras saat shutdown
+var cacheVerifyWG sync.WaitGroup
+
+func init() {
+	registerShutdownHook("cache-verify", waitGroupDrain(&cacheVerifyWG))
+}
+
+// cacheVerifyMode menentukan apakah cache hit ini perlu dicocokkan dengan database.
+// sync=true: diperiksa dalam request dan respons memakai data database bila berbeda.
+func cacheVerifyMode(r *http.Request) (verify, sync bool) {
+	if getBoolQuery(r, "verify") {
+		return true, true
+	}
+	if cacheVerifySampleRate > 0 && rand.Float64() < cacheVerifySampleRate {
+		return true, false
+	}
+	return false, false
+}
+
+// verifyListCache membandingkan isi cache daftar produk dengan hasil query terbaru.
+// Bila berbeda, perbedaan dicatat dan cache ditimpa dengan data database.
+// Mengembalikan data yang benar (cache bila sama).
+func (a *App) verifyListCache(c context.Context, cacheKey string, cached []byte, filter productFilter, limit, offset int, marshaller func(v interface{}) ([]byte, error)) []byte {
+	products, err := a.fetchProductsFromDB(c, filter, limit, offset)
+	if err != nil {
+		slog.ErrorContext(c, "Verifikasi cache gagal", "key", cacheKey, "err", err)
+		return cached
+	}
+	fresh, err := marshaller(products)
+	if err != nil {
+		slog.ErrorContext(c, "Verifikasi cache gagal", "key", cacheKey, "err", err)
+		return cached
+	}
+	if bytes.Equal(bytes.TrimSpace(fresh), bytes.TrimSpace(cached)) {
+		return cached
+	}
+	slog.WarnContext(c, "CACHE DRIFT: isi Redis berbeda dengan database, cache diperbaiki", "key", cacheKey)
+	ttl := productListCacheTTL(products)
+	if err := a.rdb.Set(c, cacheKey, fresh, ttl).Err(); err != nil {
+		slog.ErrorContext(c, "Gagal menyimpan ke Redis", "err", err)
+	}
+	if err := a.rdb.Set(c, cacheKey+listCacheETagSuffix, listETag(fresh), ttl).Err(); err != nil {
+		slog.ErrorContext(c, "Gagal menyimpan ke Redis", "err", err)
+	}
+	// Salinan gzip berasal dari body lama; dibuang dan dibuat ulang pada cache-miss berikutnya
+	if err := a.rdb.Del(c, cacheKey+listCacheGzipSuffix).Err(); err != nil {
+		slog.ErrorContext(c, "Gagal menghapus cache Redis", "err", err)
+	}
+	return fresh
+}
+
+// verifyNegativeCache memastikan produk yang di-cache sebagai 404 memang tidak ada.
+// Mengembalikan true bila sentinel salah (produk ternyata ada) dan sudah dihapus.
+func (a *App) verifyNegativeCache(c context.Context, id int) bool {
+	var exists bool
+	qc, cancel := withQueryTimeout(c)
+	defer cancel()
+	if err := a.db.QueryRowContext(qc, `SELECT EXISTS(SELECT 1 FROM products WHERE id=$1 AND deleted_at IS NULL)`, id).Scan(&exists); err != nil {
+		slog.ErrorContext(c, "Verifikasi cache produk gagal", "product_id", id, "err", err)
+		return false
+	}
+	if !exists {
+		return false
+	}
+	slog.WarnContext(c, "CACHE DRIFT: produk di-cache sebagai 404 padahal ada di database, sentinel dihapus", "product_id", id)
+	if err := a.rdb.Del(c, productCacheKey(id)).Err(); err != nil {
+		slog.ErrorContext(c, "Gagal menghapus cache Redis", "err", err)
+	}
+	return true
+}
+
+// verifyInBackground menjalankan pemeriksaan tanpa menahan respons
+func verifyInBackground(fn func(c context.Context)) {
+	cacheVerifyWG.Add(1)
+	go func() {
+		defer cacheVerifyWG.Done()
+		c, cancel := context.WithTimeout(context.Background(), cacheVerifyTimeout)
+		defer cancel()
+		fn(c)
+	}()
+}
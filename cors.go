@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Konfigurasi CORS untuk klien browser di origin lain, dibaca dari env saat startup
+type corsConfig struct {
+	AllowedOrigins   map[string]bool // kosong berarti CORS nonaktif
+	AllowAnyOrigin   bool            // CORS_ALLOWED_ORIGINS berisi "*"
+	AllowCredentials bool
+	MaxAge           int
+}
+
+// Header yang boleh dikirim dan dibaca klien browser; sesuai dengan yang dipakai handler
+const (
+	corsAllowMethods  = "GET, POST, PUT, DELETE"
+	corsAllowHeaders  = "Content-Type, Accept, Accept-Language, X-API-Key, If-Match, If-None-Match, Idempotency-Key, X-Request-ID"
+	corsExposeHeaders = "ETag, Link, Location, Retry-After, Content-Language, X-Total-Count, Idempotency-Replayed, X-Request-ID"
+)
+
+func loadCORSConfig() corsConfig {
+	cfg := corsConfig{
+		AllowedOrigins:   map[string]bool{},
+		AllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", false),
+		MaxAge:           getEnvInt("CORS_MAX_AGE", 600),
+	}
+	for _, origin := range strings.Split(getEnv("CORS_ALLOWED_ORIGINS", ""), ",") {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		switch origin {
+		case "":
+		case "*":
+			cfg.AllowAnyOrigin = true
+		default:
+			cfg.AllowedOrigins[origin] = true
+		}
+	}
+	return cfg
+}
+
+func (cfg corsConfig) enabled() bool {
+	return cfg.AllowAnyOrigin || len(cfg.AllowedOrigins) > 0
+}
+
+// corsMiddleware harus membungkus router (bukan lewat r.Use) seperti trailingSlashMiddleware:
+// preflight OPTIONS tidak cocok dengan route mana pun (semua route dibatasi Methods), sehingga
+// middleware mux tidak akan pernah dijalankan untuknya.
+func corsMiddleware(cfg corsConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !cfg.enabled() {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			if !cfg.AllowAnyOrigin && !cfg.AllowedOrigins[origin] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// Wildcard tidak boleh dipakai bersama credentials, jadi origin dipantulkan
+			if cfg.AllowAnyOrigin && !cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+				h.Set("Access-Control-Allow-Methods", corsAllowMethods)
+				h.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+				if cfg.MaxAge > 0 {
+					h.Set("Access-Control-Max-Age", strconv.Itoa(cfg.MaxAge))
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			h.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	allowList := corsConfig{AllowedOrigins: map[string]bool{"https://app.example": true}, MaxAge: 600}
+	tests := []struct {
+		name            string
+		cfg             corsConfig
+		method          string
+		origin          string
+		preflight       bool
+		wantStatus      int
+		wantAllowOrigin string
+		wantCredentials bool
+		wantNext        bool
+	}{
+		{name: "nonaktif", cfg: corsConfig{}, method: http.MethodGet, origin: "https://app.example", wantStatus: http.StatusOK, wantNext: true},
+		{name: "tanpa Origin", cfg: allowList, method: http.MethodGet, wantStatus: http.StatusOK, wantNext: true},
+		{name: "origin diizinkan", cfg: allowList, method: http.MethodGet, origin: "https://app.example", wantStatus: http.StatusOK, wantAllowOrigin: "https://app.example", wantNext: true},
+		{name: "origin lain", cfg: allowList, method: http.MethodGet, origin: "https://evil.example", wantStatus: http.StatusOK, wantNext: true},
+		{name: "preflight", cfg: allowList, method: http.MethodOptions, origin: "https://app.example", preflight: true, wantStatus: http.StatusNoContent, wantAllowOrigin: "https://app.example"},
+		{name: "OPTIONS biasa diteruskan", cfg: allowList, method: http.MethodOptions, origin: "https://app.example", wantStatus: http.StatusOK, wantAllowOrigin: "https://app.example", wantNext: true},
+		{name: "wildcard", cfg: corsConfig{AllowAnyOrigin: true}, method: http.MethodGet, origin: "https://a.example", wantStatus: http.StatusOK, wantAllowOrigin: "*", wantNext: true},
+		{
+			name: "wildcard dengan credentials memantulkan origin", cfg: corsConfig{AllowAnyOrigin: true, AllowCredentials: true},
+			method: http.MethodGet, origin: "https://a.example", wantStatus: http.StatusOK, wantAllowOrigin: "https://a.example", wantCredentials: true, wantNext: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nextCalled := false
+			h := corsMiddleware(tt.cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { nextCalled = true }))
+			r := httptest.NewRequest(tt.method, "/products/1", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				r.Header.Set("Access-Control-Request-Method", http.MethodPut)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus || nextCalled != tt.wantNext {
+				t.Fatalf("status %d next=%v, ingin %d next=%v", w.Code, nextCalled, tt.wantStatus, tt.wantNext)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllowOrigin {
+				t.Fatalf("Allow-Origin %q, ingin %q", got, tt.wantAllowOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials") == "true"; got != tt.wantCredentials {
+				t.Fatalf("Allow-Credentials %v, ingin %v", got, tt.wantCredentials)
+			}
+			if tt.preflight && (w.Header().Get("Access-Control-Allow-Methods") == "" || w.Header().Get("Access-Control-Max-Age") != "600") {
+				t.Fatalf("header preflight tidak lengkap: %v", w.Header())
+			}
+			if tt.cfg.enabled() && tt.origin != "" && w.Header().Get("Vary") == "" {
+				t.Fatal("respons bergantung Origin tanpa Vary")
+			}
+		})
+	}
+}
@@ -0,0 +1,3 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS category VARCHAR(100) NOT NULL DEFAULT 'uncategorized';
+
+CREATE INDEX IF NOT EXISTS idx_products_category ON products (category);
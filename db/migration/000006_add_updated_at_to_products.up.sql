@@ -0,0 +1,29 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_products_updated_at ON products (updated_at);
+
+CREATE OR REPLACE FUNCTION set_products_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_products_updated_at ON products;
+CREATE TRIGGER trg_products_updated_at
+    BEFORE UPDATE ON products
+    FOR EACH ROW EXECUTE FUNCTION set_products_updated_at();
+
+-- Perubahan varian mengubah ketersediaan produk induk, jadi induknya ikut "diperbarui"
+CREATE OR REPLACE FUNCTION touch_parent_product() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE products SET updated_at = CURRENT_TIMESTAMP
+    WHERE id = COALESCE(NEW.product_id, OLD.product_id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_product_variants_touch_parent ON product_variants;
+CREATE TRIGGER trg_product_variants_touch_parent
+    AFTER INSERT OR UPDATE OR DELETE ON product_variants
+    FOR EACH ROW EXECUTE FUNCTION touch_parent_product();
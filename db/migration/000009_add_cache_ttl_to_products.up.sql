@@ -0,0 +1 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS cache_ttl_seconds INT CHECK (cache_ttl_seconds > 0);
@@ -0,0 +1,36 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector);
+
+-- Satu definisi dokumen pencarian, dipakai trigger maupun endpoint reindex
+CREATE OR REPLACE FUNCTION products_search_vector(p_name TEXT, p_category TEXT, p_tags TEXT[]) RETURNS TSVECTOR AS $$
+    SELECT setweight(to_tsvector('simple', COALESCE(p_name, '')), 'A') ||
+           setweight(to_tsvector('simple', COALESCE(p_category, '')), 'B') ||
+           setweight(to_tsvector('simple', array_to_string(COALESCE(p_tags, '{}'), ' ')), 'C');
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION set_products_search_vector() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector = products_search_vector(NEW.name, NEW.category, NEW.tags);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_products_search_vector ON products;
+CREATE TRIGGER trg_products_search_vector
+    BEFORE INSERT OR UPDATE OF name, category, tags ON products
+    FOR EACH ROW EXECUTE FUNCTION set_products_search_vector();
+
+-- Perubahan yang hanya menyentuh search_vector (reindex) tidak dianggap perubahan produk
+CREATE OR REPLACE FUNCTION set_products_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    IF (to_jsonb(NEW) - 'search_vector' - 'updated_at') = (to_jsonb(OLD) - 'search_vector' - 'updated_at') THEN
+        NEW.updated_at = OLD.updated_at;
+    ELSE
+        NEW.updated_at = CURRENT_TIMESTAMP;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+UPDATE products SET search_vector = products_search_vector(name, category, tags);
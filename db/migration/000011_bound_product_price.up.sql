@@ -0,0 +1,5 @@
+-- Skala tetap 2 desimal; presisi cukup untuk batas MAX_PRICE bawaan (1e9)
+ALTER TABLE products ALTER COLUMN price TYPE NUMERIC(12, 2);
+
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_price_range;
+ALTER TABLE products ADD CONSTRAINT products_price_range CHECK (price >= 0 AND price <= 1000000000);
@@ -0,0 +1,2 @@
+-- Terjemahan per locale: {"id": {"name": "...", "description": "..."}, "en-gb": {...}}
+ALTER TABLE products ADD COLUMN IF NOT EXISTS translations JSONB NOT NULL DEFAULT '{}'::jsonb;
@@ -0,0 +1,15 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- Setiap UPDATE menaikkan versi, termasuk perubahan stok, atribut, dan tag,
+-- sehingga klien yang memegang versi lama selalu mendapat 409
+CREATE OR REPLACE FUNCTION bump_products_version() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_products_version ON products;
+CREATE TRIGGER trg_products_version
+    BEFORE UPDATE ON products
+    FOR EACH ROW EXECUTE FUNCTION bump_products_version();
@@ -0,0 +1,9 @@
+-- Baris lama tidak punya waktu pembuatan yang sebenarnya; updated_at adalah perkiraan terbaik
+ALTER TABLE products ADD COLUMN IF NOT EXISTS created_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE products DISABLE TRIGGER USER;
+UPDATE products SET created_at = updated_at WHERE created_at IS NULL;
+ALTER TABLE products ENABLE TRIGGER USER;
+ALTER TABLE products ALTER COLUMN created_at SET DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE products ALTER COLUMN created_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_products_created_at ON products (created_at);
@@ -0,0 +1,2 @@
+-- Ambang stok menipis per produk; NULL memakai LOW_STOCK_THRESHOLD global, 0 menonaktifkan
+ALTER TABLE products ADD COLUMN IF NOT EXISTS low_stock_threshold INT CHECK (low_stock_threshold >= 0);
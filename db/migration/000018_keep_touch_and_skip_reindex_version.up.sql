@@ -0,0 +1,30 @@
+-- Hanya reindex (search_vector berubah, updated_at tidak disetel eksplisit) yang mempertahankan
+-- updated_at lama. touch_parent_product (000006) menyetel updated_at eksplisit saat varian
+-- berubah, dan itu harus tetap sampai ke induk agar ETag dan cache daftar ikut berganti.
+CREATE OR REPLACE FUNCTION products_only_search_vector_changed(o products, n products) RETURNS BOOLEAN AS $$
+    SELECT n.updated_at IS NOT DISTINCT FROM o.updated_at
+       AND (to_jsonb(n) - 'search_vector' - 'updated_at' - 'version') = (to_jsonb(o) - 'search_vector' - 'updated_at' - 'version');
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION set_products_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    IF products_only_search_vector_changed(OLD, NEW) THEN
+        NEW.updated_at = OLD.updated_at;
+    ELSE
+        NEW.updated_at = CURRENT_TIMESTAMP;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Reindex bukan perubahan produk, jadi klien yang memegang versi tidak boleh mendapat 409 karenanya
+CREATE OR REPLACE FUNCTION bump_products_version() RETURNS TRIGGER AS $$
+BEGIN
+    IF products_only_search_vector_changed(OLD, NEW) THEN
+        NEW.version = OLD.version;
+    ELSE
+        NEW.version = OLD.version + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
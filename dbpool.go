@@ -0,0 +1,97 @@
+package main
+
+import (
+	"database/sql"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// configureDBPool menerapkan batas pool dari Config. Dengan beberapa replika, total koneksi
+// ke Postgres kira-kira DB_MAX_OPEN_CONNS x jumlah replika, jadi sesuaikan dengan max_connections.
+func (a *App) configureDBPool() {
+	maxOpen := a.cfg.DBMaxOpenConns
+	maxIdle := a.cfg.DBMaxIdleConns
+	lifetime := time.Duration(a.cfg.DBConnMaxLifetimeMinutes) * time.Minute
+	a.db.SetMaxOpenConns(maxOpen)
+	a.db.SetMaxIdleConns(maxIdle)
+	a.db.SetConnMaxLifetime(lifetime)
+	slog.Info("Pool database dikonfigurasi", "max_open", maxOpen, "max_idle", maxIdle, "max_lifetime", lifetime)
+}
+
+// poolMonitor mengamati db.Stats() secara berkala untuk mendeteksi query yang
+// harus menunggu koneksi bebas, sebelum pool benar-benar penuh.
+type poolMonitor struct {
+	mu        sync.Mutex
+	threshold time.Duration
+	last      struct {
+		waitCount    int64
+		waitDuration time.Duration
+	}
+	avgWait  time.Duration // rata-rata waktu tunggu per query pada interval terakhir
+	waits    int64         // jumlah query yang menunggu pada interval terakhir
+	degraded bool
+	disabled bool
+}
+
+// startPoolMonitor mengambil sampel setiap interval (DB_POOL_MONITOR_INTERVAL); interval 0
+// atau negatif menonaktifkan monitor, dan health melaporkan status "disabled"
+func (a *App) startPoolMonitor(interval, threshold time.Duration) {
+	a.dbPool.mu.Lock()
+	a.dbPool.threshold = threshold
+	a.dbPool.disabled = interval <= 0
+	a.dbPool.mu.Unlock()
+	if interval <= 0 {
+		slog.Info("Monitor pool database dinonaktifkan", "interval", interval)
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			a.dbPool.sample(a.db.Stats())
+		}
+	}()
+}
+
+func (m *poolMonitor) sample(stats sql.DBStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	waits := stats.WaitCount - m.last.waitCount
+	waited := stats.WaitDuration - m.last.waitDuration
+	m.last.waitCount, m.last.waitDuration = stats.WaitCount, stats.WaitDuration
+
+	m.waits = waits
+	m.avgWait = 0
+	if waits > 0 {
+		m.avgWait = waited / time.Duration(waits)
+	}
+	m.degraded = waits > 0 && m.avgWait > m.threshold
+	if m.degraded {
+		slog.Warn("Query menunggu koneksi database",
+			"waits", waits, "avg_wait", m.avgWait, "threshold", m.threshold,
+			"open", stats.OpenConnections, "in_use", stats.InUse, "max_open", stats.MaxOpenConnections)
+	}
+}
+
+// snapshot dipakai oleh endpoint health
+func (m *poolMonitor) snapshot(stats sql.DBStats) map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status := "ok"
+	switch {
+	case m.disabled:
+		status = "disabled"
+	case m.degraded:
+		status = "degraded"
+	}
+	return map[string]interface{}{
+		"status":          status,
+		"openConnections": stats.OpenConnections,
+		"inUse":           stats.InUse,
+		"idle":            stats.Idle,
+		"waitCount":       stats.WaitCount,
+		"recentWaits":     m.waits,
+		"recentAvgWait":   m.avgWait.String(),
+	}
+}
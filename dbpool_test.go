@@ -0,0 +1,68 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPoolMonitorSample(t *testing.T) {
+	tests := []struct {
+		name         string
+		prev, next   sql.DBStats
+		wantDegraded bool
+		wantAvg      time.Duration
+	}{
+		{name: "tanpa tunggu", next: sql.DBStats{WaitCount: 0}},
+		{name: "tunggu singkat", next: sql.DBStats{WaitCount: 4, WaitDuration: 40 * time.Millisecond}, wantAvg: 10 * time.Millisecond},
+		{name: "tunggu lama", next: sql.DBStats{WaitCount: 2, WaitDuration: 600 * time.Millisecond}, wantDegraded: true, wantAvg: 300 * time.Millisecond},
+		{
+			name:    "hanya selisih interval yang dihitung",
+			prev:    sql.DBStats{WaitCount: 10, WaitDuration: 10 * time.Second},
+			next:    sql.DBStats{WaitCount: 12, WaitDuration: 10*time.Second + 20*time.Millisecond},
+			wantAvg: 10 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &poolMonitor{threshold: 100 * time.Millisecond}
+			m.sample(tt.prev)
+			m.sample(tt.next)
+			if m.degraded != tt.wantDegraded || m.avgWait != tt.wantAvg {
+				t.Fatalf("degraded=%v avgWait=%v, ingin %v %v", m.degraded, m.avgWait, tt.wantDegraded, tt.wantAvg)
+			}
+		})
+	}
+}
+
+// Interval non-positif dari DB_POOL_MONITOR_INTERVAL tidak boleh membuat time.NewTicker panic
+func TestStartPoolMonitorDisabled(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		a, _, _ := newTestApp(t)
+		a.startPoolMonitor(interval, time.Millisecond)
+		if got := a.dbPool.snapshot(a.db.Stats())["status"]; got != "disabled" {
+			t.Fatalf("interval %v: status %v, ingin disabled", interval, got)
+		}
+	}
+}
+
+func TestHealthzReportsDBPool(t *testing.T) {
+	a, _, _ := newTestApp(t)
+	a.dbPool.degraded = true
+	w := httptest.NewRecorder()
+	a.healthHandler(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	var body struct {
+		DB     string `json:"db"`
+		DBPool struct {
+			Status string `json:"status"`
+		} `json:"dbPool"`
+	}
+	if err := jsoni.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || body.DB != "ok" || body.DBPool.Status != "degraded" {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+}
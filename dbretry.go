@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Percobaan ulang query saat error transien (koneksi putus, failover atau maintenance Postgres,
+// serialization failure). Jeda bertambah dua kali lipat dengan jitter, mulai dbRetryBaseDelay,
+// sampai dbRetryAttempts percobaan (DB_RETRY_ATTEMPTS; 1 menonaktifkan).
+var (
+	dbRetryAttempts  = 3
+	dbRetryBaseDelay = 50 * time.Millisecond
+)
+
+// Kode error PostgreSQL yang menandakan transaksi dibatalkan server sebelum ada efek apa pun
+const (
+	pqSerializationFailure = "40001"
+	pqDeadlockDetected     = "40P01"
+	pqAdminShutdown        = "57P01"
+	pqCrashShutdown        = "57P02"
+	pqCannotConnectNow     = "57P03"
+	pqConnectionClass      = "08"
+)
+
+// withRetry menjalankan fn ulang untuk error transien apa pun. Hanya untuk query baca yang
+// idempoten: koneksi yang putus di tengah query tidak memberi tahu apakah statement sudah jalan.
+func withRetry(c context.Context, fn func(c context.Context) error) error {
+	return retryDB(c, fn, isTransientReadError)
+}
+
+// withWriteRetry menjalankan fn ulang hanya bila server menjamin tidak ada yang tersimpan
+// (transaksi dibatalkan atau koneksi tidak pernah terbentuk). fn harus memuat seluruh transaksi,
+// dari BeginTx sampai Commit, agar percobaan berikutnya mengulang semuanya dari awal.
+func withWriteRetry(c context.Context, fn func(c context.Context) error) error {
+	return retryDB(c, fn, isRetryableWriteError)
+}
+
+func retryDB(c context.Context, fn func(c context.Context) error, retryable func(error) bool) error {
+	delay := dbRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := fn(c)
+		if err == nil || attempt >= dbRetryAttempts || c.Err() != nil || !retryable(err) {
+			return err
+		}
+		// Jitter agar instance yang gagal bersamaan saat failover tidak kembali serentak
+		wait := delay/2 + rand.N(delay/2+1)
+		slog.WarnContext(c, "Query database gagal sementara, mencoba lagi", "attempt", attempt, "retry_in", wait, "err", err)
+		select {
+		case <-c.Done():
+			return err
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+func isTransientReadError(err error) bool {
+	if isRetryableWriteError(err) {
+		return true
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code {
+	case pqAdminShutdown, pqCrashShutdown:
+		return true
+	}
+	return pqErr.Code.Class() == pqConnectionClass
+}
+
+func isRetryableWriteError(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code {
+	case pqSerializationFailure, pqDeadlockDetected, pqCannotConnectNow,
+		"08001", // sqlclient_unable_to_establish_sqlconnection
+		"08004": // sqlserver_rejected_establishment_of_sqlconnection
+		return true
+	}
+	return false
+}
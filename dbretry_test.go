@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestRetryClassification(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantRead  bool
+		wantWrite bool
+	}{
+		{name: "serialization failure", err: &pq.Error{Code: pqSerializationFailure}, wantRead: true, wantWrite: true},
+		{name: "deadlock", err: &pq.Error{Code: pqDeadlockDetected}, wantRead: true, wantWrite: true},
+		{name: "server sedang start", err: &pq.Error{Code: pqCannotConnectNow}, wantRead: true, wantWrite: true},
+		{name: "gagal membuka koneksi", err: &pq.Error{Code: "08001"}, wantRead: true, wantWrite: true},
+		{name: "koneksi ditolak", err: fmt.Errorf("dial: %w", syscall.ECONNREFUSED), wantRead: true, wantWrite: true},
+		// Koneksi putus setelah query terkirim: tulisan mungkin sudah tersimpan
+		{name: "admin shutdown", err: &pq.Error{Code: pqAdminShutdown}, wantRead: true},
+		{name: "kelas koneksi lain", err: &pq.Error{Code: "08006"}, wantRead: true},
+		{name: "koneksi direset", err: fmt.Errorf("read: %w", syscall.ECONNRESET), wantRead: true},
+		{name: "bad conn", err: driver.ErrBadConn, wantRead: true},
+		{name: "EOF", err: io.ErrUnexpectedEOF, wantRead: true},
+		{name: "unique violation", err: &pq.Error{Code: pqUniqueViolation}},
+		{name: "tidak ada baris", err: sql.ErrNoRows},
+		{name: "context habis", err: context.DeadlineExceeded},
+		{name: "error biasa", err: errors.New("boom")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransientReadError(tt.err); got != tt.wantRead {
+				t.Errorf("isTransientReadError = %v, ingin %v", got, tt.wantRead)
+			}
+			if got := isRetryableWriteError(tt.err); got != tt.wantWrite {
+				t.Errorf("isRetryableWriteError = %v, ingin %v", got, tt.wantWrite)
+			}
+		})
+	}
+}
+
+func TestRetryDBAttempts(t *testing.T) {
+	defer func(d time.Duration) { dbRetryBaseDelay = d }(dbRetryBaseDelay)
+	dbRetryBaseDelay = time.Millisecond
+
+	tests := []struct {
+		name      string
+		failures  int
+		err       error
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "berhasil langsung", wantCalls: 1},
+		{name: "pulih setelah dua kegagalan", failures: 2, err: &pq.Error{Code: pqSerializationFailure}, wantCalls: 3},
+		{name: "menyerah setelah batas", failures: 10, err: &pq.Error{Code: pqSerializationFailure}, wantCalls: dbRetryAttempts, wantErr: true},
+		{name: "error permanen tidak diulang", failures: 10, err: &pq.Error{Code: pqUniqueViolation}, wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := withWriteRetry(context.Background(), func(context.Context) error {
+				calls++
+				if calls <= tt.failures {
+					return tt.err
+				}
+				return nil
+			})
+			if calls != tt.wantCalls || (err != nil) != tt.wantErr {
+				t.Fatalf("calls=%d err=%v, ingin calls=%d err=%v", calls, err, tt.wantCalls, tt.wantErr)
+			}
+		})
+	}
+}
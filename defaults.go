@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// Default produk baru untuk field yang tidak dikirim klien:
+//   - stock:      DEFAULT_STOCK (0)
+//   - category:   DEFAULT_CATEGORY ("uncategorized"); juga dipakai bila category kosong
+//   - tags:       DEFAULT_TAGS, dipisah koma (kosong)
+//   - attributes: objek kosong
+//
+// name dan price wajib diisi; nilai nol eksplisit (mis. "stock": 0) tetap dihormati.
+var productDefaults = struct {
+	Stock    int
+	Category string
+	Tags     []string
+}{Stock: 0, Category: defaultCategory}
+
+func loadProductDefaults() {
+	productDefaults.Stock = getEnvInt("DEFAULT_STOCK", productDefaults.Stock)
+	if c := strings.TrimSpace(getEnv("DEFAULT_CATEGORY", "")); c != "" {
+		productDefaults.Category = c
+	}
+	if tags := getEnv("DEFAULT_TAGS", ""); tags != "" {
+		productDefaults.Tags = normalizeTags(strings.Split(tags, ","))
+	}
+}
+
+// newProductInput memakai pointer agar field yang tidak dikirim dapat dibedakan dari nilai nol
+type newProductInput struct {
+	Name       *string   `json:"name"`
+	Price      *Money    `json:"price"`
+	Stock      *int      `json:"stock"`
+	Category   *string   `json:"category"`
+	SKU        string    `json:"sku"`
+	Tags       *[]string `json:"tags"`
+	Attributes SortedMap `json:"attributes"`
+}
+
+// decodeNewProduct membaca body pembuatan produk dan mengisi default untuk field yang tidak ada
+func decodeNewProduct(body io.Reader) (Product, error) {
+	var in newProductInput
+	dec := jsoni.NewDecoder(body)
+	// Salah ketik nama field (mis. "prices") ditolak alih-alih diam-diam diabaikan
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&in); err != nil {
+		return Product{}, err
+	}
+	if in.Name == nil || strings.TrimSpace(*in.Name) == "" {
+		return Product{}, errors.New("name wajib diisi")
+	}
+	if in.Price == nil {
+		return Product{}, errors.New("price wajib diisi")
+	}
+	p := Product{
+		Name:       *in.Name,
+		Price:      *in.Price,
+		Stock:      productDefaults.Stock,
+		Category:   productDefaults.Category,
+		SKU:        in.SKU,
+		Tags:       append([]string(nil), productDefaults.Tags...),
+		Attributes: in.Attributes,
+	}
+	if in.Stock != nil {
+		p.Stock = *in.Stock
+	}
+	if in.Category != nil {
+		p.Category = *in.Category
+	}
+	if in.Tags != nil {
+		p.Tags = *in.Tags
+	}
+	return p, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// APIVersion dinaikkan setiap ada perubahan kontrak API yang tidak kompatibel
+const APIVersion = "1.0"
+
+// Media type yang dapat dikirim di header Accept untuk meminta envelope
+const envelopeMediaType = "application/vnd.pingpong.envelope+json"
+
+type envelopeMeta struct {
+	RequestID string `json:"requestId"`
+}
+
+type responseEnvelope struct {
+	APIVersion string          `json:"apiVersion"`
+	Data       json.RawMessage `json:"data"`
+	Meta       envelopeMeta    `json:"meta"`
+}
+
+// wantsEnvelope: envelope hanya dipakai jika diminta lewat ?envelope=true atau header Accept
+func wantsEnvelope(r *http.Request) bool {
+	if getBoolQuery(r, "envelope") {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), envelopeMediaType)
+}
+
+func getBoolQuery(r *http.Request, key string) bool {
+	switch strings.ToLower(r.URL.Query().Get(key)) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// bufferedResponseWriter menahan respons handler agar bisa dibungkus sebelum dikirim
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) Header() http.Header         { return b.header }
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+// envelopeMiddleware membungkus respons JSON menjadi
+// {"apiVersion":"1.0","data":...,"meta":{"requestId":"..."}} bila diminta klien.
+// Respons non-JSON (mis. error teks biasa atau 204) diteruskan apa adanya.
+func envelopeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !wantsEnvelope(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		buf := &bufferedResponseWriter{header: w.Header()}
+		next.ServeHTTP(buf, r)
+		if buf.status == 0 {
+			buf.status = http.StatusOK
+		}
+
+		body := bytes.TrimSpace(buf.body.Bytes())
+		if !strings.HasPrefix(buf.header.Get("Content-Type"), "application/json") || len(body) == 0 {
+			w.WriteHeader(buf.status)
+			w.Write(buf.body.Bytes())
+			return
+		}
+
+		requestID := requestIDFromContext(r.Context())
+		if requestID == "" {
+			requestID = newUUID()
+		}
+		out, err := jsoni.Marshal(responseEnvelope{
+			APIVersion: APIVersion,
+			Data:       json.RawMessage(body),
+			Meta:       envelopeMeta{RequestID: requestID},
+		})
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Gagal membungkus respons")
+			return
+		}
+		w.Header().Del("Content-Length")
+		w.Header().Add("Vary", "Accept")
+		w.WriteHeader(buf.status)
+		w.Write(out)
+	})
+}
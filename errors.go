@@ -0,0 +1,14 @@
+package main
+
+import "net/http"
+
+// writeJSONError adalah pengganti http.Error untuk seluruh API: status sama, tetapi body
+// berupa {"error": "..."} agar klien selalu bisa mem-parse respons error sebagai JSON.
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	jsoni.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Akhiran kunci cache daftar untuk ETag dari payload yang sama; ikut terhapus oleh "products:*"
+const listCacheETagSuffix = ":etag"
+
+// listETag adalah weak ETag satu halaman daftar produk: hash dari payload yang diserialisasi,
+// jadi berubah tepat ketika isi respons berubah. Lemah karena body identity dan gzip berbagi ETag.
+func listETag(body []byte) string {
+	h := fnv.New64a()
+	h.Write(body)
+	return fmt.Sprintf(`W/"%x"`, h.Sum64())
+}
+
+// etagMatches mengimplementasikan perbandingan lemah If-None-Match (RFC 7232 bagian 3.2):
+// header boleh berisi daftar ETag dipisah koma atau "*".
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// writeNotModified mengirim 304 tanpa body jika If-None-Match cocok
+func writeNotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	if !etagMatches(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// productETag adalah strong ETag satu produk, diturunkan dari updated_at (presisi mikrodetik Postgres)
+func productETag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMicro(), 36) + `"`
+}
+
+// ifMatchSatisfied mengimplementasikan If-Match (RFC 7232 bagian 3.1) untuk resource yang ada:
+// header berupa "*" atau daftar ETag dipisah koma, dibandingkan secara kuat, sehingga
+// ETag lemah (W/...) tidak pernah cocok.
+func ifMatchSatisfied(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || (candidate == etag && !strings.HasPrefix(candidate, "W/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchProduct memeriksa If-Match terhadap versi produk saat ini di dalam tx; baris dikunci
+// FOR UPDATE sampai tx selesai agar tidak ada penulis lain di antara cek dan update.
+// Tanpa header selalu lolos. Produk yang tidak ada gagal untuk semua nilai, termasuk "*".
+func ifMatchProduct(c context.Context, tx *sql.Tx, r *http.Request, id int) (bool, error) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true, nil
+	}
+	var updatedAt time.Time
+	err := tx.QueryRowContext(c, `SELECT updated_at FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).Scan(&updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return ifMatchSatisfied(header, productETag(updatedAt)), nil
+}
+
+// writeVersionConflict dipanggil saat UPDATE dengan version tidak mengenai baris apa pun:
+// 409 bila produk masih ada (versi sudah berubah), 404 bila produk memang tidak ada.
+func writeVersionConflict(w http.ResponseWriter, c context.Context, tx *sql.Tx, id int) {
+	var current int
+	err := tx.QueryRowContext(c, `SELECT version FROM products WHERE id = $1 AND deleted_at IS NULL`, id).Scan(&current)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+	case err != nil:
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui produk")
+	default:
+		writeJSONError(w, http.StatusConflict, "Produk telah diubah klien lain (versi sekarang "+strconv.Itoa(current)+"), ambil ulang lalu coba lagi")
+	}
+}
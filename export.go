@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/csv"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Flush ke klien setiap sekian baris agar data mulai mengalir tanpa menunggu query selesai
+const exportFlushEvery = 500
+
+// Kolom CSV ekspor; harga pokok (cost) sengaja tidak disertakan karena hanya untuk admin
+var exportCSVHeader = []string{"id", "sku", "name", "category", "price", "stock", "available_stock", "tags", "created_at", "updated_at"}
+
+// exportProductsHandler mengalirkan seluruh katalog sebagai berkas unduhan:
+// ?format=csv (default) untuk spreadsheet, atau ?format=json untuk NDJSON.
+// Filter daftar produk (?attr.<nama>=, ?updated_after=, ...) juga berlaku di sini.
+func (a *App) exportProductsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Query().Get("format") {
+	case "", "csv":
+		a.exportCSV(w, r)
+	case "json":
+		a.exportJSONLines(w, r, "products.jsonl")
+	default:
+		writeJSONError(w, http.StatusBadRequest, "format harus csv atau json")
+	}
+}
+
+// exportJSONLinesHandler mengalirkan seluruh katalog sebagai NDJSON (satu objek per baris)
+func (a *App) exportJSONLinesHandler(w http.ResponseWriter, r *http.Request) {
+	a.exportJSONLines(w, r, "")
+}
+
+func (a *App) exportJSONLines(w http.ResponseWriter, r *http.Request, filename string) {
+	enc := jsoni.NewEncoder(w) // Encode menambahkan newline setelah setiap objek
+	a.streamProducts(w, r, "application/x-ndjson", filename, func(p *Product) error {
+		return enc.Encode(p)
+	}, nil)
+}
+
+// exportCSV menulis baris header lalu satu baris per produk. csv.Writer menahan data di
+// buffer-nya sendiri, sehingga error sebelum stream dimulai masih bisa dijawab sebagai JSON.
+func (a *App) exportCSV(w http.ResponseWriter, r *http.Request) {
+	cw := csv.NewWriter(w)
+	cw.Write(exportCSVHeader)
+	record := make([]string, len(exportCSVHeader))
+	a.streamProducts(w, r, "text/csv; charset=utf-8", "products.csv", func(p *Product) error {
+		record[0] = strconv.Itoa(p.ID)
+		record[1] = csvSafe(p.SKU)
+		record[2] = csvSafe(p.Name)
+		record[3] = csvSafe(p.Category)
+		record[4] = p.Price.String()
+		record[5] = strconv.Itoa(p.Stock)
+		record[6] = strconv.Itoa(p.AvailableStock)
+		record[7] = csvSafe(strings.Join(p.Tags, ";"))
+		record[8] = p.CreatedAt.UTC().Format(time.RFC3339)
+		record[9] = p.UpdatedAt.UTC().Format(time.RFC3339)
+		return cw.Write(record)
+	}, func() error {
+		cw.Flush()
+		return cw.Error()
+	})
+}
+
+// streamProducts menjalankan query daftar produk dan memanggil emit per baris langsung dari
+// iterator, sehingga memori tetap datar berapa pun jumlah produknya. flush (opsional)
+// mengosongkan buffer penulis format sebelum data diteruskan ke klien. filename yang tidak
+// kosong dikirim sebagai Content-Disposition attachment.
+func (a *App) streamProducts(w http.ResponseWriter, r *http.Request, contentType, filename string, emit func(*Product) error, flush func() error) {
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	where, args := filter.where(nil)
+	sqlStatement := `SELECT ` + productColumns + ` FROM products p` + where + filter.orderBy()
+	// Tanpa withQueryTimeout: query ini ikut streaming sampai baris terakhir, jadi hanya
+	// dibatasi oleh batas route export-products dan pemutusan klien
+	rows, err := a.db.QueryContext(r.Context(), sqlStatement, args...)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil daftar produk")
+		return
+	}
+	defer rows.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	if filename != "" {
+		w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	}
+	flusher, _ := w.(http.Flusher)
+	flushAll := func() error {
+		if flush != nil {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	n := 0
+	for rows.Next() {
+		var p Product
+		if err := scanProduct(rows, &p); err != nil {
+			slog.ErrorContext(r.Context(), "Gagal memindai produk saat ekspor", "err", err, "rows", n)
+			abortExport()
+		}
+		localizeProduct(&p, filter.Locales)
+		if err := emit(&p); err != nil {
+			slog.InfoContext(r.Context(), "Klien terputus saat ekspor", "err", err)
+			return
+		}
+		n++
+		if n%exportFlushEvery == 0 {
+			if err := flushAll(); err != nil {
+				slog.InfoContext(r.Context(), "Klien terputus saat ekspor", "err", err)
+				return
+			}
+		}
+	}
+	// Termasuk batas waktu route (export-products/export-jsonl di defaultRouteTimeouts)
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(r.Context(), "Error saat iterasi produk untuk ekspor", "err", err, "rows", n)
+		abortExport()
+	}
+	if flush != nil {
+		if err := flush(); err != nil {
+			slog.InfoContext(r.Context(), "Klien terputus saat ekspor", "err", err)
+		}
+	}
+}
+
+// abortExport memutus koneksi di tengah stream. Status 200 dan sebagian body mungkin sudah
+// terkirim, jadi tanpa ini klien menerima berkas terpotong yang tampak lengkap; dengan
+// http.ErrAbortHandler respons berakhir tanpa penutup chunk (HTTP/1.1) atau dengan
+// RST_STREAM (HTTP/2) dan klien melihatnya sebagai error.
+func abortExport() {
+	panic(http.ErrAbortHandler)
+}
+
+// csvSafe mencegah sel teks ditafsirkan sebagai rumus saat CSV dibuka di spreadsheet
+// (CSV injection) dengan menambahkan tanda kutip tunggal di depan
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestExportProductsCSV(t *testing.T) {
+	a, mock, _ := newTestApp(t)
+	mock.ExpectQuery(`SELECT .* FROM products p WHERE p.deleted_at IS NULL ORDER BY p.id`).
+		WillReturnRows(productRows(
+			Product{ID: 1, Name: "Bola, isi 3", Price: 1999, Stock: 4, Category: "alat"},
+			Product{ID: 2, Name: "=HYPERLINK(\"x\")", Price: 500, Stock: 0, Category: "-diskon"},
+		))
+	w := httptest.NewRecorder()
+	a.exportProductsHandler(w, httptest.NewRequest(http.MethodGet, "/products/export?format=csv", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != "attachment; filename=products.csv" {
+		t.Fatalf("Content-Disposition %q", got)
+	}
+	want := "id,sku,name,category,price,stock,available_stock,tags,created_at,updated_at\n" +
+		`1,,"Bola, isi 3",alat,19.99,4,4,,2024-01-02T03:04:05Z,2024-01-02T03:04:05Z` + "\n" +
+		`2,,"'=HYPERLINK(""x"")",'-diskon,5,0,0,,2024-01-02T03:04:05Z,2024-01-02T03:04:05Z` + "\n"
+	if w.Body.String() != want {
+		t.Fatalf("CSV:\n%s\ningin:\n%s", w.Body, want)
+	}
+}
+
+func TestExportProductsInvalidFormat(t *testing.T) {
+	a, _, _ := newTestApp(t)
+	w := httptest.NewRecorder()
+	a.exportProductsHandler(w, httptest.NewRequest(http.MethodGet, "/products/export?format=xml", nil))
+	if w.Code != http.StatusBadRequest || w.Header().Get("Content-Disposition") != "" {
+		t.Fatalf("status %d, Content-Disposition %q", w.Code, w.Header().Get("Content-Disposition"))
+	}
+}
+
+// Error di tengah iterasi (mis. batas waktu route) harus memutus koneksi, bukan mengakhiri
+// respons 200 yang tampak lengkap
+func TestExportProductsAbortsOnRowError(t *testing.T) {
+	for _, format := range []string{"csv", "json"} {
+		t.Run(format, func(t *testing.T) {
+			a, mock, _ := newTestApp(t)
+			rows := productRows(Product{ID: 1, Name: "Bola", Price: 100}, Product{ID: 2, Name: "Net", Price: 100}).
+				RowError(1, errors.New("canceling statement due to statement timeout"))
+			mock.ExpectQuery(`SELECT .* FROM products p`).WillReturnRows(rows)
+
+			defer func() {
+				if rec := recover(); rec != http.ErrAbortHandler {
+					t.Fatalf("recover() = %v, ingin http.ErrAbortHandler", rec)
+				}
+			}()
+			a.exportProductsHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/products/export?format="+format, nil))
+			t.Fatal("ekspor selesai tanpa abort")
+		})
+	}
+}
+
+func TestExportRoutesHaveLongTimeout(t *testing.T) {
+	cfg := loadTimeoutConfig()
+	for _, name := range []string{"export-jsonl", "export-products"} {
+		if d := cfg.forRoute(name); d <= cfg.Default || d < time.Minute {
+			t.Errorf("route %s memakai batas waktu %v", name, d)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// productFilter menampung filter opsional untuk daftar produk.
+// Filter kosong menghasilkan kunci cache yang sama seperti tanpa filter; produk yang
+// sudah dihapus (soft delete) selalu disaring kecuali IncludeDeleted.
+type productFilter struct {
+	// Dari ?attr.<nama>=<nilai>, dicocokkan dengan operator JSONB @> (memakai indeks GIN).
+	// Nilai selalu dibandingkan sebagai string.
+	Attributes map[string]string
+
+	// Dari ?updated_after= dan ?updated_before= (RFC3339). Jika salah satu diisi,
+	// hasil diurutkan berdasarkan updated_at agar cocok untuk penarikan delta.
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+
+	// Dari ?q=, pencarian teks penuh pada search_vector (nama, kategori, tag)
+	Search string
+
+	// Dari ?name=, potongan nama tanpa membedakan huruf besar/kecil (ILIKE)
+	Name string
+
+	// Dari ?min_price= dan ?max_price=, rentang harga inklusif
+	MinPrice *Money
+	MaxPrice *Money
+
+	// Dari ?sort= dan ?order=. Sort adalah kunci productSortColumns (kosong = urutan bawaan)
+	Sort string
+	Desc bool
+
+	// Dari ?include_deleted=true, hanya untuk admin: ikut tampilkan produk yang sudah dihapus
+	IncludeDeleted bool
+
+	// Dari header Accept-Language; bukan filter baris, tetapi menentukan nama terjemahan
+	// di hasil sehingga ikut kunci cache dan ETag
+	Locales []string
+}
+
+// productSortColumns adalah whitelist ?sort=; hanya nilai peta ini yang pernah masuk ke SQL
+var productSortColumns = map[string]string{
+	"id":         "p.id",
+	"name":       "p.name",
+	"price":      "p.price",
+	"stock":      "p.stock",
+	"created_at": "p.created_at",
+	"updated_at": "p.updated_at",
+}
+
+func parseProductFilter(r *http.Request) (productFilter, error) {
+	var f productFilter
+	q := r.URL.Query()
+	f.Search = strings.TrimSpace(q.Get("q"))
+	f.Locales = parseAcceptLanguage(r.Header.Get("Accept-Language"))
+	f.Name = strings.TrimSpace(q.Get("name"))
+	if sort := strings.ToLower(strings.TrimSpace(q.Get("sort"))); sort != "" {
+		if _, ok := productSortColumns[sort]; !ok {
+			return f, errors.New("sort harus salah satu dari: id, name, price, stock, created_at, updated_at")
+		}
+		f.Sort = sort
+	}
+	switch strings.ToLower(strings.TrimSpace(q.Get("order"))) {
+	case "", "asc":
+	case "desc":
+		f.Desc = true
+	default:
+		return f, errors.New("order harus asc atau desc")
+	}
+	// Untuk non-admin parameter ini diabaikan, bukan ditolak, agar kuncinya tidak ikut cache
+	f.IncludeDeleted = isAdmin(r) && getBoolQuery(r, "include_deleted")
+	for _, param := range []struct {
+		name string
+		dest **Money
+	}{{"min_price", &f.MinPrice}, {"max_price", &f.MaxPrice}} {
+		raw := strings.TrimSpace(q.Get(param.name))
+		if raw == "" {
+			continue
+		}
+		v, err := parseMoney(raw)
+		if err != nil || v < 0 {
+			return f, errors.New(param.name + " harus berupa angka tidak negatif dengan maksimal 2 angka desimal")
+		}
+		*param.dest = &v
+	}
+	for _, param := range []struct {
+		name string
+		dest **time.Time
+	}{{"updated_after", &f.UpdatedAfter}, {"updated_before", &f.UpdatedBefore}} {
+		raw := q.Get(param.name)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return f, errors.New(param.name + " harus dalam format RFC3339, mis. 2024-01-02T15:04:05Z")
+		}
+		t = t.UTC()
+		*param.dest = &t
+	}
+	for key, values := range r.URL.Query() {
+		name, ok := strings.CutPrefix(key, "attr.")
+		if !ok || name == "" || len(values) == 0 {
+			continue
+		}
+		if f.Attributes == nil {
+			f.Attributes = map[string]string{}
+		}
+		f.Attributes[name] = values[0]
+	}
+	return f, nil
+}
+
+// orderBy mengembalikan klausa ORDER BY yang sesuai dengan filter. ?sort= eksplisit
+// didahulukan; p.id selalu menjadi pemecah seri agar paginasi stabil.
+func (f productFilter) orderBy() string {
+	column := "p.id"
+	switch {
+	case f.Sort != "":
+		column = productSortColumns[f.Sort]
+	case f.UpdatedAfter != nil || f.UpdatedBefore != nil:
+		column = "p.updated_at"
+	}
+	dir := ""
+	if f.Desc {
+		dir = " DESC"
+	}
+	if column == "p.id" {
+		return " ORDER BY p.id" + dir
+	}
+	return " ORDER BY " + column + dir + ", p.id" + dir
+}
+
+// likeEscaper meloloskan karakter wildcard LIKE agar ?name= dicocokkan secara harfiah
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// where mengembalikan klausa WHERE (diawali spasi, atau kosong) beserta argumennya.
+// Placeholder dinomori mulai dari len(args)+1.
+func (f productFilter) where(args []interface{}) (string, []interface{}) {
+	var conds []string
+	if !f.IncludeDeleted {
+		conds = append(conds, "p.deleted_at IS NULL")
+	}
+	if len(f.Attributes) > 0 {
+		attrs, _ := jsoni.Marshal(f.Attributes)
+		args = append(args, string(attrs))
+		conds = append(conds, "p.attributes @> $"+strconv.Itoa(len(args))+"::jsonb")
+	}
+	if f.Search != "" {
+		args = append(args, f.Search)
+		conds = append(conds, "p.search_vector @@ plainto_tsquery('simple', $"+strconv.Itoa(len(args))+")")
+	}
+	if f.Name != "" {
+		args = append(args, "%"+likeEscaper.Replace(f.Name)+"%")
+		conds = append(conds, "p.name ILIKE $"+strconv.Itoa(len(args)))
+	}
+	if f.MinPrice != nil {
+		args = append(args, *f.MinPrice)
+		conds = append(conds, "p.price >= $"+strconv.Itoa(len(args)))
+	}
+	if f.MaxPrice != nil {
+		args = append(args, *f.MaxPrice)
+		conds = append(conds, "p.price <= $"+strconv.Itoa(len(args)))
+	}
+	if f.UpdatedAfter != nil {
+		args = append(args, *f.UpdatedAfter)
+		conds = append(conds, "p.updated_at > $"+strconv.Itoa(len(args)))
+	}
+	if f.UpdatedBefore != nil {
+		args = append(args, *f.UpdatedBefore)
+		conds = append(conds, "p.updated_at < $"+strconv.Itoa(len(args)))
+	}
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// cacheKey mengembalikan akhiran kunci cache yang unik untuk kombinasi filter ini.
+// Nilai dari pengguna di-escape (url.QueryEscape) agar ":" dan "=" di dalamnya tidak bisa
+// meniru segmen lain, mis. ?q=a:name=b tidak boleh berbagi kunci dengan ?q=a&name=b.
+func (f productFilter) cacheKey() string {
+	var b strings.Builder
+	if f.Search != "" {
+		b.WriteString(":q=" + url.QueryEscape(f.Search))
+	}
+	if f.Name != "" {
+		// ILIKE tidak membedakan huruf, jadi variasi huruf berbagi satu kunci
+		b.WriteString(":name=" + url.QueryEscape(strings.ToLower(f.Name)))
+	}
+	if f.MinPrice != nil {
+		b.WriteString(":min_price=" + f.MinPrice.String())
+	}
+	if f.MaxPrice != nil {
+		b.WriteString(":max_price=" + f.MaxPrice.String())
+	}
+	if f.UpdatedAfter != nil {
+		b.WriteString(":updated_after=" + f.UpdatedAfter.Format(time.RFC3339Nano))
+	}
+	if f.UpdatedBefore != nil {
+		b.WriteString(":updated_before=" + f.UpdatedBefore.Format(time.RFC3339Nano))
+	}
+	if f.Sort != "" {
+		b.WriteString(":sort=" + f.Sort)
+	}
+	if f.Desc {
+		b.WriteString(":order=desc")
+	}
+	if f.IncludeDeleted {
+		b.WriteString(":include_deleted")
+	}
+	if len(f.Locales) > 0 {
+		// Tag locale sudah divalidasi localePattern, jadi tidak perlu di-escape
+		b.WriteString(":lang=" + strings.Join(f.Locales, ","))
+	}
+
+	// Nama diurutkan agar kunci tidak bergantung pada urutan map; nama dan nilai di-escape
+	// agar mis. nilai berisi ":attr.x=" tidak menyamai filter dua atribut
+	names := make([]string, 0, len(f.Attributes))
+	for name := range f.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString(":attr.")
+		b.WriteString(url.QueryEscape(name))
+		b.WriteString("=")
+		b.WriteString(url.QueryEscape(f.Attributes[name]))
+	}
+	return b.String()
+}
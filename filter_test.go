@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func filterFromQuery(t *testing.T, query string) productFilter {
+	t.Helper()
+	f, err := parseProductFilter(httptest.NewRequest("GET", "/products-standard?"+query, nil))
+	if err != nil {
+		t.Fatalf("parseProductFilter(%q): %v", query, err)
+	}
+	return f
+}
+
+// Kombinasi filter yang berbeda tidak boleh berbagi kunci cache
+func TestProductFilterCacheKeyDistinct(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{name: "separator di q", a: "q=a:name=b", b: "q=a&name=b"},
+		{name: "separator di name", a: "name=a:min_price=1", b: "name=a&min_price=1"},
+		{name: "spasi vs plus terkode", a: "q=a+b", b: "q=a%2Bb"},
+		{name: "separator di nilai atribut", a: "attr.color=red:attr.size=L", b: "attr.color=red&attr.size=L"},
+		{name: "separator di nama atribut", a: "attr.color%3Dred:attr.size=L", b: "attr.color=red&attr.size=L"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ka, kb := filterFromQuery(t, tt.a).cacheKey(), filterFromQuery(t, tt.b).cacheKey()
+			if ka == kb {
+				t.Fatalf("%q dan %q berbagi kunci %q", tt.a, tt.b, ka)
+			}
+		})
+	}
+}
+
+// Variasi yang hasil query-nya sama boleh (dan sebaiknya) berbagi kunci
+func TestProductFilterCacheKeyEquivalent(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{name: "tanpa filter", a: "", b: "limit=10"},
+		{name: "huruf besar name", a: "name=Bola", b: "name=bola"},
+		{name: "spasi di q dipangkas", a: "q=bola", b: "q=+bola+"},
+		{name: "urutan atribut", a: "attr.b=2&attr.a=1&attr.c=3", b: "attr.c=3&attr.a=1&attr.b=2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ka, kb := filterFromQuery(t, tt.a).cacheKey(), filterFromQuery(t, tt.b).cacheKey()
+			if ka != kb {
+				t.Fatalf("%q -> %q, %q -> %q", tt.a, ka, tt.b, kb)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const readinessTimeout = 2 * time.Second
+
+// appReady bernilai 1 setelah initDB (termasuk percobaan ulangnya) dan initRedis selesai.
+// Listener sudah dibuka sebelumnya agar /livez bisa dijawab selama startup.
+var appReady int32
+
+func markReady() {
+	atomic.StoreInt32(&appReady, 1)
+}
+
+func isReady() bool {
+	return atomic.LoadInt32(&appReady) == 1
+}
+
+// startupGateMiddleware menolak semua request selain probe dengan 503 selama dependensi
+// belum tersambung, karena handler lain memakai db dan rdb yang belum diinisialisasi.
+func startupGateMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isReady() && r.URL.Path != "/livez" && r.URL.Path != "/readyz" {
+			w.Header().Set("Retry-After", "1")
+			writeJSONError(w, http.StatusServiceUnavailable, "Server sedang memulai")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// livezHandler untuk liveness probe: 200 selama proses hidup dan melayani HTTP, tanpa
+// memeriksa dependensi, sehingga DB yang belum siap saat startup tidak membuat pod dibunuh
+func livezHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	jsoni.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// pingDependencies mengisi checks dengan status DB dan Redis; false bila salah satunya down
+func (a *App) pingDependencies(c context.Context, probe string, checks map[string]interface{}) bool {
+	healthy := true
+	if err := a.db.PingContext(c); err != nil {
+		slog.WarnContext(c, "Database tidak dapat dijangkau", "probe", probe, "err", err)
+		checks["db"] = "down"
+		healthy = false
+	} else {
+		checks["db"] = "ok"
+	}
+
+	if err := a.rdb.Ping(c).Err(); err != nil {
+		slog.WarnContext(c, "Redis tidak dapat dijangkau", "probe", probe, "err", err)
+		checks["redis"] = "down"
+		healthy = false
+	} else {
+		checks["redis"] = "ok"
+	}
+	return healthy
+}
+
+// healthHandler hanya memeriksa koneksi DB dan Redis, tanpa bergantung pada isi tabel.
+// Saturasi pool ikut dilaporkan sebagai sinyal "degraded" tanpa mengubah status HTTP.
+func (a *App) healthHandler(w http.ResponseWriter, r *http.Request) {
+	c, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	status := http.StatusOK
+	checks := map[string]interface{}{}
+	if !a.pingDependencies(c, "Healthz", checks) {
+		status = http.StatusServiceUnavailable
+	}
+	checks["dbPool"] = a.dbPool.snapshot(a.db.Stats())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	jsoni.NewEncoder(w).Encode(checks)
+}
+
+// readyHandler melaporkan apakah instance siap melayani trafik: startup selesai,
+// DB dan Redis dapat dijangkau, serta skema database berada di versi migrasi yang diharapkan binary.
+func (a *App) readyHandler(w http.ResponseWriter, r *http.Request) {
+	if !isReady() {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		jsoni.NewEncoder(w).Encode(map[string]string{"status": "starting"})
+		return
+	}
+	c, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	status := http.StatusOK
+	checks := map[string]interface{}{}
+	if !a.pingDependencies(c, "Readiness", checks) {
+		status = http.StatusServiceUnavailable
+	}
+
+	migration := map[string]interface{}{}
+	expected, err := expectedMigrationVersion()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Readiness: gagal membaca migrasi yang ditanam", "err", err)
+	}
+	migration["expected"] = expected
+	current, dirty, err := a.currentMigrationVersion(c)
+	switch {
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Readiness: gagal membaca versi skema", "err", err)
+		migration["status"] = "unknown"
+		status = http.StatusServiceUnavailable
+	case dirty || current != expected:
+		migration["current"] = current
+		migration["dirty"] = dirty
+		migration["status"] = fmt.Sprintf("skema di versi %d, binary mengharapkan %d", current, expected)
+		status = http.StatusServiceUnavailable
+	default:
+		migration["current"] = current
+		migration["dirty"] = dirty
+		migration["status"] = "ok"
+	}
+	checks["migration"] = migration
+
+	// Pool yang sering menunggu hanya sinyal "degraded", tidak membuat instance tidak siap
+	checks["dbPool"] = a.dbPool.snapshot(a.db.Stats())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	jsoni.NewEncoder(w).Encode(checks)
+}
@@ -0,0 +1,305 @@
+package main
+
+// Hold stok untuk checkout saat flash sale.
+//
+// POST /products/holds memeriksa dan memesan stok banyak produk dalam satu transaksi:
+// baris produk dikunci dengan FOR UPDATE SKIP LOCKED hanya selama pengecekan, lalu jumlahnya
+// dicatat sebagai reservasi di Redis (lihat reservations.go) sebelum transaksi di-commit,
+// sehingga checkout berikutnya langsung melihat stok yang sudah dipesan. Produk yang sedang
+// dikunci checkout lain dilaporkan sebagai "busy" agar klien mencoba lagi, bukan menunggu.
+//
+// Hold dilepas dengan POST /holds/{token}/confirm (stok benar-benar dikurangi),
+// DELETE /holds/{token} (dibatalkan), atau otomatis setelah HOLD_TTL.
+// Hold bekerja pada stok produk (kolom stock), bukan stok per varian.
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// Lama hold berlaku sebelum stok otomatis kembali tersedia (HOLD_TTL)
+var holdTTL = 2 * time.Minute
+
+var errInsufficientStock = errors.New("stok tidak mencukupi")
+
+type holdItem struct {
+	ID       int `json:"id"`
+	Quantity int `json:"quantity"`
+}
+
+type stockHold struct {
+	Token     string     `json:"token"`
+	Items     []holdItem `json:"items"`
+	ExpiresAt JSONTime   `json:"expiresAt"`
+}
+
+type holdConflict struct {
+	ID        int    `json:"id"`
+	Reason    string `json:"reason"` // insufficient, busy, not_found
+	Available int    `json:"available"`
+}
+
+func holdKey(token string) string {
+	return "hold:" + token
+}
+
+// createHoldHandler memesan stok untuk semua item atau tidak sama sekali
+func (a *App) createHoldHandler(w http.ResponseWriter, r *http.Request) {
+	var items []holdItem
+	if err := decodeJSONGuarded(r, &items); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(items) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "Daftar item tidak boleh kosong")
+		return
+	}
+	if len(items) > maxAvailabilityItems {
+		writeJSONError(w, http.StatusBadRequest, "Terlalu banyak item dalam satu permintaan")
+		return
+	}
+	// Gabungkan item duplikat agar satu produk hanya dikunci dan dipesan sekali
+	wanted := map[int]int{}
+	ids := make([]int, 0, len(items))
+	for _, it := range items {
+		if it.Quantity <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "quantity harus lebih dari 0")
+			return
+		}
+		if _, ok := wanted[it.ID]; !ok {
+			ids = append(ids, it.ID)
+		}
+		wanted[it.ID] += it.Quantity
+	}
+
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	tx, err := a.db.BeginTx(qc, nil)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memesan stok")
+		return
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(qc, `SELECT id, stock FROM products WHERE id = ANY($1) AND deleted_at IS NULL ORDER BY id FOR UPDATE SKIP LOCKED`, pq.Array(ids))
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memesan stok")
+		return
+	}
+	stock := map[int]int{}
+	for rows.Next() {
+		var id, s int
+		if err := rows.Scan(&id, &s); err != nil {
+			rows.Close()
+			writeJSONError(w, http.StatusInternalServerError, "Gagal memesan stok")
+			return
+		}
+		stock[id] = s
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memesan stok")
+		return
+	}
+
+	// Baris yang tidak terkunci: produk tidak ada atau sedang dipegang checkout lain
+	var missing []int
+	for _, id := range ids {
+		if _, ok := stock[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	existing := map[int]bool{}
+	if len(missing) > 0 {
+		rows, err := tx.QueryContext(qc, `SELECT id FROM products WHERE id = ANY($1) AND deleted_at IS NULL`, pq.Array(missing))
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Gagal memesan stok")
+			return
+		}
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err == nil {
+				existing[id] = true
+			}
+		}
+		rows.Close()
+	}
+
+	var conflicts []holdConflict
+	for _, id := range ids {
+		s, locked := stock[id]
+		if !locked {
+			reason := "not_found"
+			if existing[id] {
+				reason = "busy"
+			}
+			conflicts = append(conflicts, holdConflict{ID: id, Reason: reason})
+			continue
+		}
+		reserved, err := a.reservedQuantity(r.Context(), id)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Gagal membaca reservasi produk", "product_id", id, "err", err)
+			writeJSONError(w, http.StatusInternalServerError, "Gagal memesan stok")
+			return
+		}
+		if available := s - reserved; available < wanted[id] {
+			if available < 0 {
+				available = 0
+			}
+			conflicts = append(conflicts, holdConflict{ID: id, Reason: "insufficient", Available: available})
+		}
+	}
+	if len(conflicts) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		jsoni.NewEncoder(w).Encode(map[string]interface{}{"conflicts": conflicts})
+		return
+	}
+
+	hold := stockHold{Token: newRequestID(), ExpiresAt: JSONTime{time.Now().Add(holdTTL).UTC()}}
+	for _, id := range ids {
+		hold.Items = append(hold.Items, holdItem{ID: id, Quantity: wanted[id]})
+	}
+	data, err := jsoni.Marshal(hold)
+	if err == nil {
+		err = a.rdb.Set(r.Context(), holdKey(hold.Token), data, holdTTL).Err()
+	}
+	for _, it := range hold.Items {
+		if err != nil {
+			break
+		}
+		err = a.addReservation(r.Context(), it.ID, hold.Token, it.Quantity, holdTTL)
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Gagal menyimpan hold", "token", hold.Token, "err", err)
+		a.releaseHold(r.Context(), &hold)
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memesan stok")
+		return
+	}
+	// Kunci baris dilepas di sini; reservasi di Redis yang menahan stok sampai hold selesai
+	if err := tx.Commit(); err != nil {
+		a.releaseHold(r.Context(), &hold)
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memesan stok")
+		return
+	}
+
+	w.Header().Set("Location", "/holds/"+hold.Token)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	jsoni.NewEncoder(w).Encode(hold)
+}
+
+// claimHold mengambil dan menghapus hold secara atomik agar tidak dikonfirmasi dua kali
+func (a *App) claimHold(r *http.Request) (*stockHold, error) {
+	data, err := a.rdb.GetDel(r.Context(), holdKey(mux.Vars(r)["token"])).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var hold stockHold
+	if err := jsoni.Unmarshal(data, &hold); err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// releaseHold menghapus reservasi milik hold
+func (a *App) releaseHold(c context.Context, hold *stockHold) {
+	c = detachedContext(c)
+	a.rdb.Del(c, holdKey(hold.Token))
+	for _, it := range hold.Items {
+		if err := a.removeReservation(c, it.ID, hold.Token); err != nil {
+			slog.ErrorContext(c, "Gagal menghapus reservasi hold", "token", hold.Token, "product_id", it.ID, "err", err)
+		}
+	}
+}
+
+// confirmHoldHandler mengurangi stok sesuai hold lalu melepas reservasinya
+func (a *App) confirmHoldHandler(w http.ResponseWriter, r *http.Request) {
+	hold, err := a.claimHold(r)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "Hold tidak ditemukan atau sudah kedaluwarsa")
+		return
+	}
+
+	// Seluruh transaksi diulang bila Postgres membatalkannya (serialization failure, deadlock)
+	// Batas waktu berlaku untuk semua percobaan sekaligus
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	err = withWriteRetry(qc, func(c context.Context) error {
+		tx, err := a.db.BeginTx(c, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		for _, it := range hold.Items {
+			var remaining int
+			err := tx.QueryRowContext(c, `UPDATE products SET stock = stock - $1 WHERE id = $2 AND stock >= $1 AND deleted_at IS NULL RETURNING stock`, it.Quantity, it.ID).Scan(&remaining)
+			if errors.Is(err, sql.ErrNoRows) {
+				return errInsufficientStock
+			}
+			if err != nil {
+				return err
+			}
+			if err := recordAudit(c, tx, it.ID, stockChange(remaining+it.Quantity, remaining)); err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		// Hold dikembalikan selama sisa waktunya agar klien dapat mencoba lagi atau membatalkan
+		if remaining := time.Until(hold.ExpiresAt.Time); remaining > 0 {
+			if data, mErr := jsoni.Marshal(hold); mErr == nil {
+				a.rdb.Set(detachedContext(r.Context()), holdKey(hold.Token), data, remaining)
+			}
+		}
+		if errors.Is(err, errInsufficientStock) {
+			writeJSONError(w, http.StatusConflict, "Stok tidak lagi mencukupi untuk hold ini")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Gagal mengonfirmasi hold", "token", hold.Token, "err", err)
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mengonfirmasi hold")
+		return
+	}
+
+	a.releaseHold(r.Context(), hold)
+	ids := make([]int, len(hold.Items))
+	for i, it := range hold.Items {
+		ids[i] = it.ID
+	}
+	a.invalidateManyProductCaches(r.Context(), ids)
+	for _, id := range ids {
+		a.invalidateCategoryStockForProduct(r.Context(), id)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	jsoni.NewEncoder(w).Encode(hold)
+}
+
+// cancelHoldHandler melepas hold tanpa mengubah stok
+func (a *App) cancelHoldHandler(w http.ResponseWriter, r *http.Request) {
+	hold, err := a.claimHold(r)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "Hold tidak ditemukan atau sudah kedaluwarsa")
+		return
+	}
+	a.releaseHold(r.Context(), hold)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getHoldHandler menampilkan hold yang masih aktif
+func (a *App) getHoldHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := a.rdb.Get(r.Context(), holdKey(mux.Vars(r)["token"])).Bytes()
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "Hold tidak ditemukan atau sudah kedaluwarsa")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
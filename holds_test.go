@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreateHold(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		lockedStock   map[int]int // baris yang berhasil dikunci
+		unlocked      bool        // ada id yang tidak terkunci sehingga keberadaannya dicek ulang
+		existing      []int       // baris yang ada tetapi tidak terkunci (busy)
+		wantStatus    int
+		wantConflicts []holdConflict
+	}{
+		{name: "body kosong", body: `[]`, wantStatus: http.StatusBadRequest},
+		{name: "quantity nol", body: `[{"id":1,"quantity":0}]`, wantStatus: http.StatusBadRequest},
+		{name: "berhasil", body: `[{"id":1,"quantity":2},{"id":2,"quantity":1}]`, lockedStock: map[int]int{1: 5, 2: 1}, wantStatus: http.StatusCreated},
+		{
+			name: "item duplikat digabung", body: `[{"id":1,"quantity":3},{"id":1,"quantity":3}]`, lockedStock: map[int]int{1: 5},
+			wantStatus: http.StatusConflict, wantConflicts: []holdConflict{{ID: 1, Reason: "insufficient", Available: 5}},
+		},
+		{
+			name: "busy dan not_found", body: `[{"id":1,"quantity":1},{"id":2,"quantity":1}]`, unlocked: true, existing: []int{1},
+			wantStatus: http.StatusConflict, wantConflicts: []holdConflict{{ID: 1, Reason: "busy"}, {ID: 2, Reason: "not_found"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, mock, _ := newTestApp(t)
+			if tt.wantStatus != http.StatusBadRequest {
+				mock.ExpectBegin()
+				locked := sqlmock.NewRows([]string{"id", "stock"})
+				for _, id := range []int{1, 2} {
+					if s, ok := tt.lockedStock[id]; ok {
+						locked.AddRow(id, s)
+					}
+				}
+				mock.ExpectQuery(`SELECT id, stock FROM products WHERE id = ANY\(\$1\) AND deleted_at IS NULL ORDER BY id FOR UPDATE SKIP LOCKED`).WillReturnRows(locked)
+				if tt.unlocked {
+					existing := sqlmock.NewRows([]string{"id"})
+					for _, id := range tt.existing {
+						existing.AddRow(id)
+					}
+					mock.ExpectQuery(`SELECT id FROM products WHERE id = ANY\(\$1\) AND deleted_at IS NULL`).WillReturnRows(existing)
+				}
+				if tt.wantStatus == http.StatusCreated {
+					mock.ExpectCommit()
+				} else {
+					mock.ExpectRollback()
+				}
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/products/holds", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			a.newRouter(trailingSlashIgnore, false).ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status %d, ingin %d; body: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+
+			switch tt.wantStatus {
+			case http.StatusConflict:
+				var body struct {
+					Conflicts []holdConflict `json:"conflicts"`
+				}
+				if err := jsoni.Unmarshal(w.Body.Bytes(), &body); err != nil {
+					t.Fatal(err)
+				}
+				if len(body.Conflicts) != len(tt.wantConflicts) {
+					t.Fatalf("conflicts %+v, ingin %+v", body.Conflicts, tt.wantConflicts)
+				}
+				for i := range body.Conflicts {
+					if body.Conflicts[i] != tt.wantConflicts[i] {
+						t.Fatalf("conflicts %+v, ingin %+v", body.Conflicts, tt.wantConflicts)
+					}
+				}
+			case http.StatusCreated:
+				var hold stockHold
+				if err := jsoni.Unmarshal(w.Body.Bytes(), &hold); err != nil || hold.Token == "" {
+					t.Fatalf("hold tidak valid: %s (%v)", w.Body, err)
+				}
+				if got := w.Header().Get("Location"); got != "/holds/"+hold.Token {
+					t.Fatalf("Location %q", got)
+				}
+				for id, want := range map[int]int{1: 2, 2: 1} {
+					if got, err := a.reservedQuantity(context.Background(), id); err != nil || got != want {
+						t.Fatalf("reservasi produk %d = %d, ingin %d (%v)", id, got, want, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+// Hold yang dibatalkan melepas reservasinya dan tidak bisa dibatalkan dua kali
+func TestCancelHoldReleasesReservations(t *testing.T) {
+	a, mock, _ := newTestApp(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, stock FROM products`).WillReturnRows(sqlmock.NewRows([]string{"id", "stock"}).AddRow(1, 3))
+	mock.ExpectCommit()
+	router := a.newRouter(trailingSlashIgnore, false)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/products/holds", strings.NewReader(`[{"id":1,"quantity":3}]`)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("membuat hold: status %d; body: %s", w.Code, w.Body)
+	}
+	var hold stockHold
+	if err := jsoni.Unmarshal(w.Body.Bytes(), &hold); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []int{http.StatusNoContent, http.StatusNotFound} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/holds/"+hold.Token, nil))
+		if w.Code != want {
+			t.Fatalf("DELETE ke-%d: status %d, ingin %d", i+1, w.Code, want)
+		}
+	}
+	if got, err := a.reservedQuantity(context.Background(), 1); err != nil || got != 0 {
+		t.Fatalf("reservasi tersisa %d (%v)", got, err)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Idempotency-Key untuk request tulis yang sering di-retry klien (mis. POST /products).
+//
+// Respons pertama untuk satu kunci disimpan di Redis selama idempotencyTTL; request berikutnya
+// dengan kunci yang sama mendapat salinan respons itu tanpa menjalankan handler lagi. Request
+// bersamaan dengan kunci yang sama diserialkan lewat kunci lock: yang datang belakangan menunggu
+// hasil yang pertama. Respons 5xx tidak disimpan agar retry tetap bisa berhasil.
+
+var (
+	idempotencyTTL = 24 * time.Hour
+	// Batas lama satu request memegang lock; lebih dari batas waktu request agar tidak kedaluwarsa di tengah jalan
+	idempotencyLockTTL = time.Minute
+)
+
+// releaseIdempotencyLockScript hanya menghapus lock yang masih dipegang pemilik token. Bila
+// handler melewati idempotencyLockTTL dan request lain sudah mengambil lock, lock itu tidak disentuh.
+var releaseIdempotencyLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+
+const (
+	idempotencyMaxKeyLength = 255
+	idempotencyPollInterval = 50 * time.Millisecond
+)
+
+// Header respons yang ikut disimpan dan diputar ulang
+var idempotentHeaders = []string{"Content-Type", "Location", "ETag"}
+
+type idempotentResponse struct {
+	// Sidik jari body request; kunci yang sama dengan body berbeda ditolak
+	RequestHash string            `json:"requestHash"`
+	Status      int               `json:"status"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+}
+
+func idempotencyCacheKey(r *http.Request, key string) string {
+	return "idempotency:" + r.Method + ":" + r.URL.Path + ":" + key
+}
+
+func (a *App) loadIdempotentResponse(c context.Context, cacheKey string) (*idempotentResponse, bool) {
+	data, err := a.rdb.Get(c, cacheKey).Bytes()
+	if err != nil {
+		return nil, false
+	}
+	var resp idempotentResponse
+	if err := jsoni.Unmarshal(data, &resp); err != nil {
+		slog.Warn("Respons idempoten rusak, diabaikan", "key", cacheKey, "err", err)
+		return nil, false
+	}
+	return &resp, true
+}
+
+// writeIdempotentResponse memutar ulang respons tersimpan, atau 422 bila body request berbeda
+func writeIdempotentResponse(w http.ResponseWriter, resp *idempotentResponse, requestHash string) {
+	if resp.RequestHash != requestHash {
+		writeJSONError(w, http.StatusUnprocessableEntity, "Idempotency-Key sudah dipakai untuk request dengan body berbeda")
+		return
+	}
+	for k, v := range resp.Headers {
+		w.Header().Set(k, v)
+	}
+	w.Header().Set("Idempotency-Replayed", "true")
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// idempotent membungkus handler tulis agar menghormati header Idempotency-Key.
+// Tanpa header, atau bila Redis tidak dapat dipakai, handler dijalankan seperti biasa.
+func (a *App) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > idempotencyMaxKeyLength {
+			writeJSONError(w, http.StatusBadRequest, "Idempotency-Key terlalu panjang")
+			return
+		}
+		body, err := readRequestBody(w, r)
+		if err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(sum[:])
+
+		cacheKey := idempotencyCacheKey(r, key)
+		lockKey := cacheKey + ":lock"
+		lockToken := newRequestID()
+		for {
+			if resp, ok := a.loadIdempotentResponse(r.Context(), cacheKey); ok {
+				writeIdempotentResponse(w, resp, requestHash)
+				return
+			}
+			acquired, err := a.rdb.SetNX(r.Context(), lockKey, lockToken, idempotencyLockTTL).Result()
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Gagal memasang lock idempotensi, request diproses tanpa perlindungan", "err", err)
+				next(w, r)
+				return
+			}
+			if acquired {
+				break
+			}
+			select {
+			case <-r.Context().Done():
+				writeJSONError(w, http.StatusConflict, "Request dengan Idempotency-Key yang sama masih diproses")
+				return
+			case <-time.After(idempotencyPollInterval):
+			}
+		}
+		defer releaseIdempotencyLockScript.Run(detachedContext(r.Context()), a.rdb, []string{lockKey}, lockToken)
+
+		// Request lain bisa saja selesai tepat sebelum lock kita dapatkan
+		if resp, ok := a.loadIdempotentResponse(r.Context(), cacheKey); ok {
+			writeIdempotentResponse(w, resp, requestHash)
+			return
+		}
+
+		buf := &bufferedResponseWriter{header: w.Header()}
+		next(buf, r)
+		if buf.status == 0 {
+			buf.status = http.StatusOK
+		}
+		if buf.status < http.StatusInternalServerError {
+			resp := idempotentResponse{RequestHash: requestHash, Status: buf.status, Headers: map[string]string{}, Body: buf.body.Bytes()}
+			for _, h := range idempotentHeaders {
+				if v := buf.header.Get(h); v != "" {
+					resp.Headers[h] = v
+				}
+			}
+			if data, err := jsoni.Marshal(resp); err != nil {
+				slog.ErrorContext(r.Context(), "Gagal mem-format respons idempoten", "err", err)
+			} else if err := a.rdb.Set(detachedContext(r.Context()), cacheKey, data, idempotencyTTL).Err(); err != nil {
+				slog.ErrorContext(r.Context(), "Gagal menyimpan respons idempoten", "err", err)
+			}
+		}
+		w.WriteHeader(buf.status)
+		w.Write(buf.body.Bytes())
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotentReplaysFirstResponse(t *testing.T) {
+	a, _, _ := newTestApp(t)
+	calls := 0
+	status := http.StatusCreated
+	h := a.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Location", "/products/1")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"id":1}`))
+	})
+	do := func(key, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+		if key != "" {
+			r.Header.Set("Idempotency-Key", key)
+		}
+		w := httptest.NewRecorder()
+		h(w, r)
+		return w
+	}
+
+	first := do("k1", `{"name":"a"}`)
+	second := do("k1", `{"name":"a"}`)
+	if calls != 1 {
+		t.Fatalf("handler dipanggil %d kali, ingin 1", calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() ||
+		second.Header().Get("Location") != "/products/1" || second.Header().Get("Idempotency-Replayed") != "true" {
+		t.Fatalf("replay tidak sama: %d %v %s", second.Code, second.Header(), second.Body)
+	}
+
+	if w := do("k1", `{"name":"b"}`); w.Code != http.StatusUnprocessableEntity || calls != 1 {
+		t.Fatalf("body berbeda: status %d, calls %d", w.Code, calls)
+	}
+	if w := do("", `{"name":"a"}`); w.Code != http.StatusCreated || calls != 2 {
+		t.Fatalf("tanpa kunci: status %d, calls %d", w.Code, calls)
+	}
+	if w := do(strings.Repeat("x", idempotencyMaxKeyLength+1), `{}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("kunci terlalu panjang: status %d", w.Code)
+	}
+}
+
+// Respons 5xx tidak disimpan agar retry klien masih bisa berhasil
+func TestIdempotentDoesNotStoreServerErrors(t *testing.T) {
+	a, _, _ := newTestApp(t)
+	calls := 0
+	h := a.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			writeJSONError(w, http.StatusServiceUnavailable, "coba lagi")
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	for i, want := range []int{http.StatusServiceUnavailable, http.StatusCreated, http.StatusCreated} {
+		r := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(`{}`))
+		r.Header.Set("Idempotency-Key", "k2")
+		w := httptest.NewRecorder()
+		h(w, r)
+		if w.Code != want {
+			t.Fatalf("request ke-%d: status %d, ingin %d", i+1, w.Code, want)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("handler dipanggil %d kali, ingin 2", calls)
+	}
+}
+
+// Handler yang melewati idempotencyLockTTL tidak boleh menghapus lock milik request berikutnya
+func TestIdempotentReleasesOnlyOwnLock(t *testing.T) {
+	a, _, mr := newTestApp(t)
+	var lockKey string
+	h := a.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		lockKey = idempotencyCacheKey(r, "k3") + ":lock"
+		// Lock kedaluwarsa di tengah handler dan diambil request lain
+		mr.FastForward(idempotencyLockTTL + time.Second)
+		if mr.Exists(lockKey) {
+			t.Error("lock lama belum kedaluwarsa")
+		}
+		mr.Set(lockKey, "milik-request-lain")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	r := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(`{}`))
+	r.Header.Set("Idempotency-Key", "k3")
+	h(httptest.NewRecorder(), r)
+
+	if got, err := mr.Get(lockKey); err != nil || got != "milik-request-lain" {
+		t.Fatalf("lock request lain terhapus: %q (%v)", got, err)
+	}
+}
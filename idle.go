@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// idleTracker mencatat aktivitas request untuk mode idle-shutdown (scale-to-zero)
+type idleTracker struct {
+	inFlight     int64
+	lastActivity int64 // UnixNano
+}
+
+func newIdleTracker() *idleTracker {
+	return &idleTracker{lastActivity: time.Now().UnixNano()}
+}
+
+func (t *idleTracker) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&t.inFlight, 1)
+		defer func() {
+			atomic.StoreInt64(&t.lastActivity, time.Now().UnixNano())
+			atomic.AddInt64(&t.inFlight, -1)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (t *idleTracker) idleFor() time.Duration {
+	if atomic.LoadInt64(&t.inFlight) > 0 {
+		return 0
+	}
+	return time.Since(time.Unix(0, atomic.LoadInt64(&t.lastActivity)))
+}
+
+// watch memanggil stop setelah tidak ada request selama idle; Shutdown sendiri dijalankan
+// oleh main, sama seperti saat menerima SIGTERM. Berhenti bila c sudah selesai.
+func (t *idleTracker) watch(c context.Context, idle time.Duration, stop func()) {
+	interval := idle / 10
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+		}
+		if t.idleFor() < idle {
+			continue
+		}
+		slog.InfoContext(c, "Tidak ada request, server dimatikan (IDLE_SHUTDOWN)", "idle", idle)
+		stop()
+		return
+	}
+}
@@ -0,0 +1,416 @@
+//go:build integration
+
+package main
+
+// Test integrasi end-to-end terhadap Postgres dan Redis sungguhan yang dijalankan
+// testcontainers-go (butuh Docker):
+//
+//	go test -tags integration ./...
+//
+// Test memakai router yang sama dengan server (newRouter/wrapRouter) di atas satu App yang
+// tersambung ke container, sehingga jalur cache dan invalidasi yang diuji persis jalur produksi.
+// Karena semua test berbagi database dan Redis yang sama, test di file ini tidak boleh memakai t.Parallel.
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	tcredis "github.com/testcontainers/testcontainers-go/modules/redis"
+)
+
+var (
+	testApp    *App
+	testServer *httptest.Server
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(runIntegration(m))
+}
+
+func runIntegration(m *testing.M) int {
+	c := context.Background()
+	pg, err := postgres.Run(c, "postgres:16-alpine",
+		postgres.WithDatabase("pingpong"),
+		postgres.WithUsername("pingpong"),
+		postgres.WithPassword("pingpong"),
+		postgres.BasicWaitStrategies(),
+	)
+	if err != nil {
+		log.Printf("Gagal menjalankan container Postgres: %v", err)
+		return 1
+	}
+	defer testcontainers.TerminateContainer(pg)
+	rd, err := tcredis.Run(c, "redis:7-alpine")
+	if err != nil {
+		log.Printf("Gagal menjalankan container Redis: %v", err)
+		return 1
+	}
+	defer testcontainers.TerminateContainer(rd)
+
+	dsn, err := pg.ConnectionString(c, "sslmode=disable")
+	if err != nil {
+		log.Printf("Gagal membaca alamat Postgres: %v", err)
+		return 1
+	}
+	redisAddr, err := rd.Endpoint(c, "")
+	if err != nil {
+		log.Printf("Gagal membaca alamat Redis: %v", err)
+		return 1
+	}
+
+	// Batas rate limit default terlalu kecil untuk serangkaian request dari satu IP
+	os.Setenv("RATE_LIMIT_READ_BURST", "100000")
+	os.Setenv("RATE_LIMIT_WRITE_BURST", "100000")
+	// Tanpa jendela read-your-writes agar cache daftar langsung terisi setelah penulisan
+	readAfterWriteWindow = 0
+
+	cfg := defaultConfig()
+	cfg.DatabaseURL = dsn
+	cfg.RedisURL = redisAddr
+	testApp = newApp(cfg)
+	testApp.initDB()
+	defer testApp.db.Close()
+	if err := testApp.runMigrations(c); err != nil {
+		log.Printf("Gagal menjalankan migrasi: %v", err)
+		return 1
+	}
+	testApp.initRedis()
+	defer testApp.rdb.Close()
+	markReady()
+
+	testServer = httptest.NewServer(wrapRouter(testApp.newRouter(trailingSlashIgnore, false), trailingSlashIgnore))
+	defer testServer.Close()
+	return m.Run()
+}
+
+// resetState mengosongkan tabel dan Redis agar setiap test mulai dari nol
+func resetState(t *testing.T) {
+	t.Helper()
+	if _, err := testApp.db.Exec(`TRUNCATE products, audit_log RESTART IDENTITY CASCADE`); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	if err := testApp.rdb.FlushDB(context.Background()).Err(); err != nil {
+		t.Fatalf("flush redis: %v", err)
+	}
+}
+
+func doRequest(t *testing.T, method, path, body string, header ...string) (*http.Response, []byte) {
+	t.Helper()
+	var r io.Reader
+	if body != "" {
+		r = bytes.NewBufferString(body)
+	}
+	req, err := http.NewRequest(method, testServer.URL+path, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := testServer.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, data
+}
+
+func expectStatus(t *testing.T, resp *http.Response, body []byte, want int) {
+	t.Helper()
+	if resp.StatusCode != want {
+		t.Fatalf("%s %s: status %d, ingin %d; body: %s", resp.Request.Method, resp.Request.URL.Path, resp.StatusCode, want, body)
+	}
+}
+
+func createTestProduct(t *testing.T, name string, stock int) Product {
+	t.Helper()
+	resp, body := doRequest(t, http.MethodPost, "/products", fmt.Sprintf(`{"name":%q,"price":19.99,"stock":%d,"category":"test"}`, name, stock))
+	expectStatus(t, resp, body, http.StatusCreated)
+	var p Product
+	if err := jsoni.Unmarshal(body, &p); err != nil {
+		t.Fatalf("decode produk: %v", err)
+	}
+	if want := "/products/" + strconv.Itoa(p.ID); resp.Header.Get("Location") != want {
+		t.Fatalf("Location %q, ingin %q", resp.Header.Get("Location"), want)
+	}
+	return p
+}
+
+func getTestProduct(t *testing.T, id int) Product {
+	t.Helper()
+	resp, body := doRequest(t, http.MethodGet, "/products/"+strconv.Itoa(id), "")
+	expectStatus(t, resp, body, http.StatusOK)
+	var p Product
+	if err := jsoni.Unmarshal(body, &p); err != nil {
+		t.Fatalf("decode produk: %v", err)
+	}
+	return p
+}
+
+func redisHasKey(t *testing.T, key string) bool {
+	t.Helper()
+	n, err := testApp.rdb.Exists(context.Background(), key).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n > 0
+}
+
+// listCacheKeys mengembalikan kunci cache halaman daftar produk (termasuk salinan :gz dan :etag)
+func listCacheKeys(t *testing.T) []string {
+	t.Helper()
+	keys, err := testApp.rdb.Keys(context.Background(), "products:limit=*").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestProductCRUD(t *testing.T) {
+	resetState(t)
+	created := createTestProduct(t, "Bola Pingpong", 10)
+
+	got := getTestProduct(t, created.ID)
+	if got.Name != "Bola Pingpong" || got.Stock != 10 || got.Price.String() != "19.99" {
+		t.Fatalf("produk tidak sesuai: %+v", got)
+	}
+	if !redisHasKey(t, productCacheKey(created.ID)) {
+		t.Fatal("GET tidak mengisi cache produk")
+	}
+
+	resp, body := doRequest(t, http.MethodPut, "/products/"+strconv.Itoa(created.ID), `{"name":"Bola Pingpong Pro","price":25,"stock":7}`)
+	expectStatus(t, resp, body, http.StatusOK)
+	if redisHasKey(t, productCacheKey(created.ID)) {
+		t.Fatal("update tidak menghapus cache produk")
+	}
+	if got := getTestProduct(t, created.ID); got.Name != "Bola Pingpong Pro" || got.Stock != 7 {
+		t.Fatalf("produk setelah update tidak sesuai: %+v", got)
+	}
+
+	resp, body = doRequest(t, http.MethodDelete, "/products/"+strconv.Itoa(created.ID), "")
+	expectStatus(t, resp, body, http.StatusNoContent)
+	resp, body = doRequest(t, http.MethodGet, "/products/"+strconv.Itoa(created.ID), "")
+	expectStatus(t, resp, body, http.StatusNotFound)
+}
+
+func TestGetProductServedFromCache(t *testing.T) {
+	resetState(t)
+	p := createTestProduct(t, "Raket", 5)
+	getTestProduct(t, p.ID)
+
+	// Perubahan langsung di database (tanpa handler) tidak terlihat selama cache masih ada
+	if _, err := testApp.db.Exec(`UPDATE products SET name = 'Raket Diubah' WHERE id = $1`, p.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := getTestProduct(t, p.ID); got.Name != "Raket" {
+		t.Fatalf("GET kedua tidak dilayani dari cache: %q", got.Name)
+	}
+	testApp.invalidateProductCaches(context.Background(), p.ID)
+	if got := getTestProduct(t, p.ID); got.Name != "Raket Diubah" {
+		t.Fatalf("setelah invalidasi masih membaca cache: %q", got.Name)
+	}
+}
+
+func TestProductListCacheAndInvalidation(t *testing.T) {
+	resetState(t)
+	a := createTestProduct(t, "Meja", 3)
+	createTestProduct(t, "Net", 8)
+
+	resp, body := doRequest(t, http.MethodGet, "/products-standard", "")
+	expectStatus(t, resp, body, http.StatusOK)
+	var list []Product
+	if err := jsoni.Unmarshal(body, &list); err != nil || len(list) != 2 {
+		t.Fatalf("daftar tidak sesuai (%v): %s", err, body)
+	}
+	if len(listCacheKeys(t)) == 0 {
+		t.Fatal("GET /products-standard tidak mengisi cache daftar")
+	}
+
+	// Cache hit: perubahan langsung di database belum terlihat
+	if _, err := testApp.db.Exec(`UPDATE products SET name = 'Meja Lipat' WHERE id = $1`, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, cached := doRequest(t, http.MethodGet, "/products-standard", "")
+	if !bytes.Equal(cached, body) {
+		t.Fatalf("GET kedua tidak dilayani dari cache:\n%s\n%s", body, cached)
+	}
+
+	// Tulis lewat API menghapus semua halaman daftar, jadi GET berikutnya membaca database
+	resp, body = doRequest(t, http.MethodPut, "/products/"+strconv.Itoa(a.ID)+"/stock", `{"stock":1}`)
+	expectStatus(t, resp, body, http.StatusOK)
+	if keys := listCacheKeys(t); len(keys) != 0 {
+		t.Fatalf("update stok tidak menghapus cache daftar: %v", keys)
+	}
+	_, body = doRequest(t, http.MethodGet, "/products-standard", "")
+	if !bytes.Contains(body, []byte("Meja Lipat")) {
+		t.Fatalf("daftar setelah invalidasi tidak membaca database: %s", body)
+	}
+}
+
+func TestProductListETag(t *testing.T) {
+	resetState(t)
+	createTestProduct(t, "Bet", 4)
+
+	resp, body := doRequest(t, http.MethodGet, "/products-standard", "")
+	expectStatus(t, resp, body, http.StatusOK)
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("daftar tanpa ETag")
+	}
+	resp, body = doRequest(t, http.MethodGet, "/products-standard", "", "If-None-Match", etag)
+	expectStatus(t, resp, body, http.StatusNotModified)
+
+	createTestProduct(t, "Bet Cadangan", 2)
+	resp, body = doRequest(t, http.MethodGet, "/products-standard", "", "If-None-Match", etag)
+	expectStatus(t, resp, body, http.StatusOK)
+}
+
+func TestUpdateStockRecordsHistory(t *testing.T) {
+	resetState(t)
+	p := createTestProduct(t, "Sepatu", 10)
+
+	resp, body := doRequest(t, http.MethodPut, "/products/"+strconv.Itoa(p.ID)+"/stock", `{"delta":-4}`)
+	expectStatus(t, resp, body, http.StatusOK)
+	if got := getTestProduct(t, p.ID); got.Stock != 6 {
+		t.Fatalf("stok %d, ingin 6", got.Stock)
+	}
+	resp, body = doRequest(t, http.MethodPut, "/products/"+strconv.Itoa(p.ID)+"/stock", `{"delta":-7}`)
+	expectStatus(t, resp, body, http.StatusConflict)
+
+	resp, body = doRequest(t, http.MethodGet, "/products/"+strconv.Itoa(p.ID)+"/history", "")
+	expectStatus(t, resp, body, http.StatusOK)
+	var history []auditEntry
+	if err := jsoni.Unmarshal(body, &history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Field != "stock" || *history[0].OldValue != "10" || *history[0].NewValue != "6" {
+		t.Fatalf("riwayat tidak sesuai: %s", body)
+	}
+}
+
+func TestReserveStock(t *testing.T) {
+	resetState(t)
+	p := createTestProduct(t, "Kaos", 5)
+	getTestProduct(t, p.ID)
+
+	path := "/products/" + strconv.Itoa(p.ID) + "/reserve"
+	resp, body := doRequest(t, http.MethodPost, path, `{"quantity":3}`)
+	expectStatus(t, resp, body, http.StatusOK)
+	if redisHasKey(t, productCacheKey(p.ID)) {
+		t.Fatal("reservasi tidak menghapus cache produk")
+	}
+	resp, body = doRequest(t, http.MethodPost, path, `{"quantity":3}`)
+	expectStatus(t, resp, body, http.StatusConflict)
+	var conflict struct {
+		Available int `json:"available"`
+	}
+	if err := jsoni.Unmarshal(body, &conflict); err != nil || conflict.Available != 2 {
+		t.Fatalf("409 tanpa stok tersedia yang benar: %s", body)
+	}
+
+	// Idempotency-Key yang sama tidak mengurangi stok dua kali
+	for i := 0; i < 2; i++ {
+		resp, body = doRequest(t, http.MethodPost, path, `{"quantity":1}`, "Idempotency-Key", "checkout-1")
+		expectStatus(t, resp, body, http.StatusOK)
+	}
+	if got := getTestProduct(t, p.ID); got.Stock != 1 {
+		t.Fatalf("stok %d, ingin 1", got.Stock)
+	}
+}
+
+func TestReserveStockRespectsHold(t *testing.T) {
+	resetState(t)
+	p := createTestProduct(t, "Topi", 5)
+
+	resp, body := doRequest(t, http.MethodPost, "/products/holds", fmt.Sprintf(`[{"id":%d,"quantity":5}]`, p.ID))
+	expectStatus(t, resp, body, http.StatusCreated)
+	var hold stockHold
+	if err := jsoni.Unmarshal(body, &hold); err != nil {
+		t.Fatal(err)
+	}
+
+	// Seluruh stok dijanjikan ke hold, jadi reserve tidak boleh mengambilnya
+	resp, body = doRequest(t, http.MethodPost, "/products/"+strconv.Itoa(p.ID)+"/reserve", `{"quantity":5}`)
+	expectStatus(t, resp, body, http.StatusConflict)
+	resp, body = doRequest(t, http.MethodPost, "/holds/"+hold.Token+"/confirm", "")
+	expectStatus(t, resp, body, http.StatusOK)
+	if got := getTestProduct(t, p.ID); got.Stock != 0 {
+		t.Fatalf("stok %d setelah konfirmasi hold, ingin 0", got.Stock)
+	}
+}
+
+func productVersionRow(t *testing.T, id int) (time.Time, int) {
+	t.Helper()
+	var updatedAt time.Time
+	var version int
+	if err := testApp.db.QueryRow(`SELECT updated_at, version FROM products WHERE id = $1`, id).Scan(&updatedAt, &version); err != nil {
+		t.Fatal(err)
+	}
+	return updatedAt, version
+}
+
+func TestVariantTouchesParentAndReindexKeepsVersion(t *testing.T) {
+	resetState(t)
+	p := createTestProduct(t, "Jersey", 0)
+	before, _ := productVersionRow(t, p.ID)
+
+	time.Sleep(10 * time.Millisecond)
+	resp, body := doRequest(t, http.MethodPost, "/products/"+strconv.Itoa(p.ID)+"/variants", `{"sku":"JRS-M","stock":3}`)
+	expectStatus(t, resp, body, http.StatusCreated)
+	touched, version := productVersionRow(t, p.ID)
+	if !touched.After(before) {
+		t.Fatalf("updated_at induk tidak bergerak setelah varian dibuat: %v -> %v", before, touched)
+	}
+
+	// Statement yang sama dengan reindexSearchHandler
+	if _, err := testApp.db.Exec(`UPDATE products p SET search_vector = products_search_vector(p.name, p.category, p.tags) WHERE id = $1`, p.ID); err != nil {
+		t.Fatal(err)
+	}
+	afterReindex, versionAfter := productVersionRow(t, p.ID)
+	if !afterReindex.Equal(touched) || versionAfter != version {
+		t.Fatalf("reindex mengubah produk: updated_at %v -> %v, version %d -> %d", touched, afterReindex, version, versionAfter)
+	}
+}
+
+func TestInvalidProductID(t *testing.T) {
+	resetState(t)
+	resp, body := doRequest(t, http.MethodGet, "/products/abc", "")
+	expectStatus(t, resp, body, http.StatusNotFound)
+	// Cocok dengan [0-9]+ tetapi melebihi int
+	resp, body = doRequest(t, http.MethodGet, "/products/99999999999999999999", "")
+	expectStatus(t, resp, body, http.StatusBadRequest)
+}
+
+func TestNegativeCache(t *testing.T) {
+	resetState(t)
+	if negativeCacheTTL <= 0 {
+		t.Skip("NEGATIVE_CACHE_TTL_SECONDS menonaktifkan negative cache")
+	}
+	resp, body := doRequest(t, http.MethodGet, "/products/424242", "")
+	expectStatus(t, resp, body, http.StatusNotFound)
+	cached, err := testApp.rdb.Get(context.Background(), productCacheKey(424242)).Result()
+	if err != nil || cached != cacheNilSentinel {
+		t.Fatalf("404 tidak di-cache sebagai sentinel: %q (%v)", cached, err)
+	}
+	if ttl := testApp.rdb.TTL(context.Background(), productCacheKey(424242)).Val(); ttl <= 0 || ttl > negativeCacheTTL+time.Second {
+		t.Fatalf("TTL sentinel %v", ttl)
+	}
+}
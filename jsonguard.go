@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// Batas struktur body JSON untuk endpoint bulk (JSON_MAX_DEPTH, JSON_MAX_ELEMENTS)
+var (
+	jsonMaxDepth    = 10
+	jsonMaxElements = 10000
+)
+
+// Batas ukuran body yang dibaca oleh decodeJSONGuarded
+const jsonGuardMaxBytes = 4 << 20
+
+// Batas ukuran body endpoint tulis produk (MAX_BODY_BYTES), dipasang lewat limitRequestBody
+var maxBodyBytes int64 = 1 << 20
+
+// limitRequestBody membungkus r.Body dengan http.MaxBytesReader sehingga pembacaan melewati
+// maxBodyBytes gagal dengan *http.MaxBytesError (lihat writeBodyError)
+func limitRequestBody(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+}
+
+// readRequestBody membaca seluruh body dengan batas maxBodyBytes. Body dibaca utuh lebih dulu
+// karena decoder jsoniter tidak meneruskan tipe error pembaca, sehingga 413 tak bisa dibedakan.
+func readRequestBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	limitRequestBody(w, r)
+	return io.ReadAll(r.Body)
+}
+
+// writeBodyError mengirim 413 bila body melewati batas ukuran, selain itu 400
+func writeBodyError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, "Body melebihi batas "+strconv.FormatInt(tooLarge.Limit, 10)+" byte")
+		return
+	}
+	writeJSONError(w, http.StatusBadRequest, err.Error())
+}
+
+// decodeJSONGuarded memindai token body terlebih dahulu (streaming, tanpa membangun nilai)
+// untuk menolak payload yang terlalu dalam atau terlalu banyak elemen, baru kemudian
+// melakukan unmarshal penuh. Error yang dikembalikan layak dikirim sebagai 400.
+func decodeJSONGuarded(r *http.Request, v interface{}) error {
+	body, err := io.ReadAll(io.LimitReader(r.Body, jsonGuardMaxBytes+1))
+	if err != nil {
+		return err // termasuk *http.MaxBytesError bila limitRequestBody dipasang
+	}
+	if len(body) > jsonGuardMaxBytes {
+		return errors.New("body terlalu besar")
+	}
+	if err := scanJSONShape(body, jsonMaxDepth, jsonMaxElements); err != nil {
+		return err
+	}
+	return jsoni.Unmarshal(body, v)
+}
+
+func scanJSONShape(body []byte, maxDepth, maxElements int) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	depth, elements := 0, 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if d, ok := tok.(json.Delim); ok {
+			switch d {
+			case '[', '{':
+				depth++
+				if depth > maxDepth {
+					return errors.New("JSON terlalu dalam (maksimal " + strconv.Itoa(maxDepth) + " tingkat)")
+				}
+			case ']', '}':
+				depth--
+			}
+			continue
+		}
+		elements++
+		if elements > maxElements {
+			return errors.New("JSON memiliki terlalu banyak elemen (maksimal " + strconv.Itoa(maxElements) + ")")
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// SortedMap adalah objek JSON dinamis yang selalu diserialisasi dengan kunci terurut.
+// Dengan begitu data yang identik menghasilkan byte cache (dan ETag) yang identik,
+// apa pun marshaller yang dipakai (encoding/json maupun jsoniter).
+type SortedMap map[string]interface{}
+
+func (m SortedMap) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		// encoding/json mengurutkan kunci map bersarang di dalam nilai
+		val, err := json.Marshal(m[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
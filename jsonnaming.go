@@ -0,0 +1,46 @@
+package main
+
+// Konvensi penamaan field JSON: semua field API memakai camelCase lewat struct tag
+// (mis. "cacheTtlSeconds", "availableStock", "createdAt"). Field multi-kata baru wajib
+// mengikuti konvensi ini.
+//
+// Selama masa deprecation, input dengan bentuk snake_case lama ("cache_ttl_seconds") juga
+// diterima: snakeCaseInputExtension menambahkan nama snake_case sebagai alias decode untuk
+// setiap field struct yang didecode lewat jsoni. Output selalu camelCase. Alias dapat
+// dimatikan dengan JSON_ACCEPT_SNAKE_CASE=false setelah semua klien bermigrasi.
+
+import (
+	"strings"
+	"unicode"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+type snakeCaseInputExtension struct {
+	jsoniter.DummyExtension
+}
+
+func (*snakeCaseInputExtension) UpdateStructDescriptor(sd *jsoniter.StructDescriptor) {
+	for _, binding := range sd.Fields {
+		for _, name := range binding.FromNames {
+			if snake := camelToSnake(name); snake != name {
+				binding.FromNames = append(binding.FromNames, snake)
+			}
+		}
+	}
+}
+
+// camelToSnake mengubah "cacheTtlSeconds" menjadi "cache_ttl_seconds"
+func camelToSnake(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
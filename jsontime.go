@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Format serialisasi timestamp di JSON (TIME_FORMAT): rfc3339 (default), unix (detik),
+// atau unixmilli. Berlaku global agar byte cache konsisten untuk semua klien.
+const (
+	timeFormatRFC3339   = "rfc3339"
+	timeFormatUnix      = "unix"
+	timeFormatUnixMilli = "unixmilli"
+)
+
+var jsonTimeFormat = timeFormatRFC3339
+
+func loadJSONTimeFormat() error {
+	switch f := getEnv("TIME_FORMAT", timeFormatRFC3339); f {
+	case timeFormatRFC3339, timeFormatUnix, timeFormatUnixMilli:
+		jsonTimeFormat = f
+		return nil
+	default:
+		return errors.New("TIME_FORMAT harus rfc3339, unix, atau unixmilli, bukan " + strconv.Quote(f))
+	}
+}
+
+// JSONTime adalah time.Time yang diserialisasi sesuai jsonTimeFormat
+type JSONTime struct {
+	time.Time
+}
+
+func (t JSONTime) MarshalJSON() ([]byte, error) {
+	switch jsonTimeFormat {
+	case timeFormatUnix:
+		return strconv.AppendInt(nil, t.Unix(), 10), nil
+	case timeFormatUnixMilli:
+		return strconv.AppendInt(nil, t.UnixMilli(), 10), nil
+	}
+	return t.Time.MarshalJSON()
+}
+
+// UnmarshalJSON menerima semua format, karena data di Redis bisa ditulis sebelum
+// TIME_FORMAT diubah. Angka di atas 1e11 dianggap milidetik.
+func (t *JSONTime) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		return t.Time.UnmarshalJSON(data)
+	}
+	n, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return err
+	}
+	if n > 1e11 {
+		t.Time = time.UnixMilli(n).UTC()
+	} else {
+		t.Time = time.Unix(n, 0).UTC()
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// parseListenAddr menentukan jaringan dari LISTEN_ADDR: "unix:/path" atau path absolut/relatif
+// yang diawali "/" atau "." berarti Unix domain socket, selain itu alamat TCP (mis. ":8080").
+func parseListenAddr(addr string) (network, address string) {
+	if strings.HasPrefix(addr, "unix:") {
+		return "unix", strings.TrimPrefix(addr, "unix:")
+	}
+	if strings.HasPrefix(addr, "/") || strings.HasPrefix(addr, ".") {
+		return "unix", addr
+	}
+	return "tcp", addr
+}
+
+// listen membuka listener untuk LISTEN_ADDR. Untuk Unix socket, file sisa dari proses
+// sebelumnya (mis. setelah crash) dihapus dulu, dan fungsi cleanup menghapus file socket
+// setelah server berhenti.
+func listen(addr string) (net.Listener, func(), error) {
+	network, address := parseListenAddr(addr)
+	if network != "unix" {
+		ln, err := net.Listen(network, address)
+		return ln, func() {}, err
+	}
+
+	if fi, err := os.Stat(address); err == nil {
+		if fi.Mode()&fs.ModeSocket == 0 {
+			return nil, nil, errors.New(address + " sudah ada dan bukan socket")
+		}
+		if err := os.Remove(address); err != nil {
+			return nil, nil, err
+		}
+	}
+	ln, err := net.Listen("unix", address)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Proxy (mis. nginx) di pod yang sama biasanya berjalan sebagai user lain
+	mode, err := strconv.ParseUint(getEnv("LISTEN_SOCKET_MODE", "0660"), 8, 32)
+	if err != nil {
+		ln.Close()
+		return nil, nil, errors.New("LISTEN_SOCKET_MODE harus berupa mode oktal, mis. 0660")
+	}
+	if mode > 0 {
+		if err := os.Chmod(address, fs.FileMode(mode)); err != nil {
+			ln.Close()
+			return nil, nil, err
+		}
+	}
+	cleanup := func() {
+		if err := os.Remove(address); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			slog.Error("Gagal menghapus file socket", "path", address, "err", err)
+		}
+	}
+	return ln, cleanup, nil
+}
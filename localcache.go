@@ -0,0 +1,48 @@
+package main
+
+import (
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+// Cache L1 di memori proses untuk daftar produk, dipakai HANYA saat Redis error (bukan
+// redis.Nil), agar instance tetap bisa melayani baca selama Redis padam sebentar.
+//
+// Selama Redis sehat, L1 tidak pernah dibaca: invalidasi dari instance lain hanya sampai ke
+// Redis, sehingga membaca L1 lebih dulu akan menyajikan data basi. L1 cukup diisi setiap kali
+// cache daftar ditulis atau terbaca dari Redis, dan dikosongkan oleh invalidateProductListCaches.
+// Entri kedaluwarsa setelah LOCAL_CACHE_TTL untuk membatasi seberapa basi data saat Redis padam.
+var localListCache *expirable.LRU[string, []byte]
+
+// initLocalListCache membaca LOCAL_CACHE_SIZE (jumlah entri, default 1000; 0 menonaktifkan)
+// dan LOCAL_CACHE_TTL (default 1m)
+func initLocalListCache() {
+	size := getEnvInt("LOCAL_CACHE_SIZE", 1000)
+	if size <= 0 {
+		return
+	}
+	localListCache = expirable.NewLRU[string, []byte](size, nil, getEnvDuration("LOCAL_CACHE_TTL", time.Minute))
+}
+
+func setLocalListCache(key string, data []byte) {
+	if localListCache != nil {
+		localListCache.Add(key, data)
+	}
+}
+
+// getLocalListCache mengembalikan salinan lokal hanya bila err dari Redis adalah kegagalan
+// koneksi/perintah; redis.Nil berarti kunci memang tidak ada (atau sudah diinvalidasi)
+func getLocalListCache(key string, err error) ([]byte, bool) {
+	if localListCache == nil || err == nil || err == redis.Nil {
+		return nil, false
+	}
+	return localListCache.Get(key)
+}
+
+func purgeLocalListCache() {
+	if localListCache != nil {
+		localListCache.Purge()
+	}
+}
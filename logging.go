@@ -0,0 +1,77 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// initLogger memasang slog dengan output JSON sebagai logger default. Level diatur lewat
+// LOG_LEVEL (debug, info, warn, error; default info); pesan CACHE HIT/MISS ada di level debug.
+// Pemanggilan log.* yang tersisa (mis. log.Fatal saat startup) ikut diteruskan ke slog.
+// Record yang dicatat dengan context request mendapat atribut request_id.
+func initLogger() {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(getEnv("LOG_LEVEL", "info"))); err != nil {
+		level = slog.LevelInfo
+	}
+	slog.SetDefault(slog.New(requestIDLogHandler{slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})}))
+}
+
+// responseWriter mencatat status code yang dikirim handler untuk log request
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	return rw.ResponseWriter.Write(p)
+}
+
+// Flush diteruskan agar streaming (mis. ekspor NDJSON) tetap berjalan di balik wrapper
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// loggingMiddleware mencatat satu baris per request: method, path, status, durasi,
+// jumlah query database, alamat klien, dan request_id. Dipasang sebelum queryCountMiddleware
+// agar penghitung query yang dibuat di sini dipakai bersama.
+func loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, n := withQueryCounter(r.Context())
+		rw := &responseWriter{ResponseWriter: w}
+		start := time.Now()
+		next.ServeHTTP(rw, r.WithContext(c))
+
+		status := rw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		slog.InfoContext(c, "request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"duration_ms", float64(time.Since(start).Microseconds())/1000,
+			"queries", atomic.LoadInt64(n),
+			"remote_addr", r.RemoteAddr,
+		)
+	})
+}
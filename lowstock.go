@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Webhook stok menipis: saat update stok membuat stok turun melewati ambang (low_stock_threshold
+// per produk, atau LOW_STOCK_THRESHOLD global), event dikirim lewat POST JSON ke WEBHOOK_URL.
+// Pengiriman berjalan di goroutine terpisah sehingga tidak menahan respons; kegagalan dicoba
+// ulang hingga webhookAttempts kali dengan jeda bertambah, lalu hanya dicatat di log.
+var (
+	webhookURL        string
+	lowStockThreshold = 0 // 0 = nonaktif, kecuali produk memiliki ambang sendiri
+	webhookAttempts   = 3
+	webhookRetryDelay = time.Second
+)
+
+var webhookClient = &http.Client{Timeout: 5 * time.Second}
+
+// Pengiriman webhook yang masih berjalan, dikuras saat shutdown
+var webhookWG sync.WaitGroup
+
+func init() {
+	registerShutdownHook("low-stock-webhook", waitGroupDrain(&webhookWG))
+}
+
+type lowStockEvent struct {
+	Event         string   `json:"event"`
+	Product       Product  `json:"product"`
+	Stock         int      `json:"stock"`
+	PreviousStock int      `json:"previousStock"`
+	Threshold     int      `json:"threshold"`
+	RequestID     string   `json:"requestId,omitempty"`
+	At            JSONTime `json:"at"`
+}
+
+// lowStockCrossed mengembalikan ambang yang berlaku dan apakah stok baru saja turun melewatinya.
+// Stok yang sudah di bawah ambang sebelum update tidak memicu event lagi.
+func lowStockCrossed(old, new int, perProduct sql.NullInt64) (int, bool) {
+	threshold := lowStockThreshold
+	if perProduct.Valid {
+		threshold = int(perProduct.Int64)
+	}
+	return threshold, threshold > 0 && old >= threshold && new < threshold
+}
+
+// notifyLowStock dipanggil setelah commit update stok
+func (a *App) notifyLowStock(c context.Context, id, old, new int, perProduct sql.NullInt64) {
+	if webhookURL == "" {
+		return
+	}
+	threshold, crossed := lowStockCrossed(old, new, perProduct)
+	if !crossed {
+		return
+	}
+	// Tetap membawa request_id untuk log, tetapi tidak ikut batal saat request selesai
+	c = context.WithoutCancel(c)
+	event := lowStockEvent{
+		Event:         "product.low_stock",
+		Stock:         new,
+		PreviousStock: old,
+		Threshold:     threshold,
+		RequestID:     requestIDFromContext(c),
+		At:            JSONTime{time.Now()},
+	}
+	webhookWG.Add(1)
+	go func() {
+		defer webhookWG.Done()
+		qc, cancel := withQueryTimeout(c)
+		err := scanProduct(a.db.QueryRowContext(qc, `SELECT `+productColumns+` FROM products p WHERE p.id = $1`, id), &event.Product)
+		cancel()
+		if err != nil {
+			slog.ErrorContext(c, "Gagal memuat produk untuk webhook stok menipis", "product_id", id, "err", err)
+			return
+		}
+		body, err := jsoni.Marshal(event)
+		if err != nil {
+			slog.ErrorContext(c, "Gagal mem-format webhook stok menipis", "product_id", id, "err", err)
+			return
+		}
+		sendWebhook(c, body, id)
+	}()
+}
+
+// sendWebhook mengirim body ke WEBHOOK_URL; status selain 2xx dianggap gagal dan dicoba ulang
+func sendWebhook(c context.Context, body []byte, id int) {
+	delay := webhookRetryDelay
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		err := postWebhook(c, body)
+		if err == nil {
+			slog.InfoContext(c, "Webhook stok menipis terkirim", "product_id", id, "attempt", attempt)
+			return
+		}
+		if attempt == webhookAttempts {
+			slog.ErrorContext(c, "Webhook stok menipis gagal, menyerah", "product_id", id, "attempts", attempt, "err", err)
+			return
+		}
+		slog.WarnContext(c, "Webhook stok menipis gagal, mencoba lagi", "product_id", id, "attempt", attempt, "retry_in", delay, "err", err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func postWebhook(c context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(c, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if id := requestIDFromContext(c); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -1,73 +1,298 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/gorilla/mux"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	jsoniter "github.com/json-iterator/go"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"golang.org/x/sync/singleflight"
 )
 
 var (
-	db    *sql.DB
-	rdb   *redis.Client
-	ctx   = context.Background()
 	jsoni = jsoniter.ConfigCompatibleWithStandardLibrary
+
+	// Kirim header Link (RFC 5988) pada respons daftar produk
+	paginationLinks = true
 )
 
+const cacheKeyProductCount = "products:count"
+
+// TTL cache global; produk dapat menimpanya lewat cache_ttl_seconds
+var cacheTTL = 10 * time.Minute
+
+// Sentinel untuk negative caching: id yang tidak ada di database
+const cacheNilSentinel = "__nil__"
+
+// TTL sentinel 404 pada getProductHandler; 0 berarti negative caching nonaktif
+var negativeCacheTTL = 30 * time.Second
+
+func productCacheKey(id int) string {
+	return fmt.Sprintf("product:%d", id)
+}
+
 // ... (Struct Product dan fungsi main tetap sama) ...
 type Product struct {
-	ID    int     `json:"id"`
-	Name  string  `json:"name"`
-	Price float64 `json:"price"`
-	Stock int     `json:"stock"`
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Price Money  `json:"price"`
+	Stock int    `json:"stock"`
+
+	Category string `json:"category"`
+
+	// Kunci natural opsional; unik jika diisi
+	SKU string `json:"sku,omitempty"`
+
+	Tags []string `json:"tags"`
+
+	// Override TTL cache untuk produk ini (mis. barang flash sale); nil = TTL global
+	CacheTTLSeconds *int `json:"cacheTtlSeconds,omitempty"`
+
+	// Harga pokok, hanya untuk admin. Tidak pernah ikut serialisasi publik (lihat adminProduct)
+	Cost *Money `json:"-"`
+
+	// Atribut bebas per kategori (mis. voltage, material), disimpan sebagai JSONB
+	Attributes SortedMap `json:"attributes"`
+
+	// Dihitung dari varian jika produk memiliki varian, selain itu sama dengan Stock
+	AvailableStock int  `json:"availableStock"`
+	Available      bool `json:"available"`
+
+	// Terjemahan per locale; nama (dan deskripsi) dilokalkan sesuai Accept-Language
+	Translations map[string]productTranslation `json:"-"`
+	Description  string                        `json:"description,omitempty"`
+	Locale       string                        `json:"locale,omitempty"`
+
+	// Waktu produk dibuat; dipakai untuk ?sort=created_at (mis. tampilan "produk terbaru")
+	CreatedAt JSONTime `json:"createdAt"`
+
+	// Versi produk untuk ETag dan If-Match (diperbarui trigger setiap perubahan)
+	UpdatedAt JSONTime `json:"updatedAt"`
+
+	// Nomor versi untuk optimistic concurrency: kirim kembali saat update, 409 bila sudah berubah.
+	// Dinaikkan trigger pada setiap UPDATE; 0 pada body update berarti tidak diperiksa.
+	Version int `json:"version"`
+
+	// Waktu soft delete; hanya terisi pada daftar admin dengan ?include_deleted=true
+	DeletedAt *JSONTime `json:"deletedAt,omitempty"`
+}
+
+// Kolom yang dipilih untuk setiap query produk (alias tabel: p).
+// Stok tersedia adalah total stok varian, atau stok produk itu sendiri jika tidak ada varian.
+const productColumns = `p.id, p.name, p.price, p.stock, p.category, COALESCE(p.sku, ''), p.tags, p.cost, p.cache_ttl_seconds, p.attributes,
+	COALESCE((SELECT SUM(v.stock) FROM product_variants v WHERE v.product_id = p.id), p.stock), p.created_at, p.updated_at, p.translations, p.version, p.deleted_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProduct memindai satu baris hasil query yang memakai productColumns
+func scanProduct(row rowScanner, p *Product) error {
+	var attrs, translations []byte
+	var deletedAt sql.NullTime
+	if err := row.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.Category, &p.SKU, pq.Array(&p.Tags), &p.Cost, &p.CacheTTLSeconds, &attrs, &p.AvailableStock, &p.CreatedAt.Time, &p.UpdatedAt.Time, &translations, &p.Version, &deletedAt); err != nil {
+		return err
+	}
+	if deletedAt.Valid {
+		p.DeletedAt = &JSONTime{deletedAt.Time}
+	}
+	if err := jsoni.Unmarshal(translations, &p.Translations); err != nil {
+		return err
+	}
+	p.Available = p.AvailableStock > 0
+	if p.Tags == nil {
+		p.Tags = []string{}
+	}
+	p.Attributes = SortedMap{}
+	return jsoni.Unmarshal(attrs, &p.Attributes)
 }
 
 func main() {
-	dbConnStr := os.Getenv("DATABASE_URL")
-	redisURL := os.Getenv("REDIS_URL")
+	initLogger()
+	migrateOnly := flag.Bool("migrate", false, "jalankan migrasi database lalu keluar (untuk init container)")
+	flag.Parse()
 
-	if dbConnStr == "" || redisURL == "" {
-		log.Fatal("DATABASE_URL atau REDIS_URL tidak disetel")
+	if *migrateOnly {
+		cfg, err := loadConfig(false)
+		if err != nil {
+			log.Fatalf("Konfigurasi tidak valid:\n%v", err)
+		}
+		app := newApp(cfg)
+		app.initDB()
+		err = app.runMigrations(context.Background())
+		app.db.Close()
+		if err != nil {
+			log.Fatalf("Gagal menjalankan migrasi: %v", err)
+		}
+		return
 	}
 
-	initDB(dbConnStr)
-	initRedis(redisURL)
-	defer db.Close()
+	cfg, err := LoadConfig()
+	if err != nil {
+		log.Fatalf("Konfigurasi tidak valid:\n%v", err)
+	}
+	app := newApp(cfg)
 
-	r := mux.NewRouter()
-	r.HandleFunc("/products-standard", getProductsStandardHandler).Methods("GET")
-	r.HandleFunc("/products-iterator", getProductsIteratorHandler).Methods("GET")
-	r.HandleFunc("/products", createProductHandler).Methods("POST")
-	r.HandleFunc("/products/{id}", getProductHandler).Methods("GET")
-	r.HandleFunc("/products/{id}/stock", updateStockHandler).Methods("PUT")
+	adminAPIKey = os.Getenv("ADMIN_API_KEY")
+	if err := loadJSONTimeFormat(); err != nil {
+		log.Fatal(err)
+	}
+	maxPrice = getEnvFloat("MAX_PRICE", maxPrice)
+	// Alias snake_case untuk input selama masa deprecation (lihat jsonnaming.go)
+	if getEnvBool("JSON_ACCEPT_SNAKE_CASE", true) {
+		jsoni.RegisterExtension(&snakeCaseInputExtension{})
+	}
+	jsonMaxDepth = getEnvInt("JSON_MAX_DEPTH", jsonMaxDepth)
+	jsonMaxElements = getEnvInt("JSON_MAX_ELEMENTS", jsonMaxElements)
+	paginationLinks = getEnvBool("PAGINATION_LINK_HEADER", true)
+	maxCompareItems = getEnvInt("COMPARE_MAX_ITEMS", maxCompareItems)
+	cacheTTL = time.Duration(cfg.CacheTTLSeconds) * time.Second
+	negativeCacheTTL = getEnvSeconds("NEGATIVE_CACHE_TTL_SECONDS", negativeCacheTTL)
+	bestEffortDeadline = getEnvDuration("BEST_EFFORT_DEADLINE", bestEffortDeadline)
+	cacheVerifySampleRate = getEnvFloat("CACHE_VERIFY_SAMPLE_RATE", cacheVerifySampleRate)
+	queryCountWarnThreshold = getEnvInt("QUERY_COUNT_WARN_THRESHOLD", queryCountWarnThreshold)
+	holdTTL = getEnvDuration("HOLD_TTL", holdTTL)
+	idempotencyTTL = getEnvDuration("IDEMPOTENCY_TTL", idempotencyTTL)
+	maxBodyBytes = int64(getEnvInt("MAX_BODY_BYTES", int(maxBodyBytes)))
+	dbRetryAttempts = getEnvInt("DB_RETRY_ATTEMPTS", dbRetryAttempts)
+	webhookURL = os.Getenv("WEBHOOK_URL")
+	lowStockThreshold = getEnvInt("LOW_STOCK_THRESHOLD", lowStockThreshold)
+	webhookAttempts = getEnvInt("WEBHOOK_ATTEMPTS", webhookAttempts)
+	loadProductDefaults()
+	cacheWriteNX = getEnvBool("CACHE_WRITE_NX", cacheWriteNX)
+	listCacheMaxBytes = getEnvInt("LIST_CACHE_MAX_BYTES", listCacheMaxBytes)
+	dbQueryTimeout = getEnvDuration("DB_QUERY_TIMEOUT", dbQueryTimeout)
+	stockLevelCacheTTL = getEnvDuration("STOCK_LEVEL_CACHE_TTL", stockLevelCacheTTL)
+	readAfterWriteWindow = getEnvDuration("READ_AFTER_WRITE_WINDOW", readAfterWriteWindow)
+	cacheLatencyWindow = getEnvDuration("CACHE_LATENCY_WINDOW", cacheLatencyWindow)
+	initBulkLimiter(getEnvInt("BULK_MAX_CONCURRENT", cap(bulkSlots)), getEnvDuration("BULK_QUEUE_TIMEOUT", bulkQueueWait))
 
-	log.Println("Server berjalan di http://localhost:8080")
-	log.Fatal(http.ListenAndServe(":8080", r))
+	trailingSlash := getEnv("TRAILING_SLASH", trailingSlashIgnore)
+	writeQueueEnabled := getEnvBool("WRITE_QUEUE_ENABLED", false)
+	r := app.newRouter(trailingSlash, writeQueueEnabled)
+	handler := wrapRouter(r, trailingSlash)
+
+	// h2c: HTTP/2 tanpa TLS, untuk gateway/proxy yang memultipleks banyak request
+	// dalam satu koneksi plaintext. Lewat TLS, http.Server sudah mendukung HTTP/2 otomatis.
+	if getEnvBool("H2C_ENABLED", false) {
+		handler = h2c.NewHandler(handler, &http2.Server{})
+		slog.Info("HTTP/2 cleartext (h2c) aktif")
+	}
+
+	ln, cleanupListener, err := listen(cfg.ListenAddr)
+	if err != nil {
+		log.Fatalf("Gagal membuka listener %s: %v", cfg.ListenAddr, err)
+	}
+	srv := &http.Server{Handler: handler}
+
+	// SIGINT/SIGTERM (mis. saat rolling deploy) atau idle-shutdown memicu Shutdown: listener
+	// ditutup dan request yang sedang berjalan diberi waktu SHUTDOWN_TIMEOUT untuk selesai.
+	stopCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Mode scale-to-zero (opt-in): matikan server setelah idle selama IDLE_SHUTDOWN (mis. "15m")
+	if idle := getEnvDuration("IDLE_SHUTDOWN", 0); idle > 0 {
+		tracker := newIdleTracker()
+		srv.Handler = tracker.middleware(handler)
+		go tracker.watch(stopCtx, idle, stop)
+		slog.Info("Idle-shutdown aktif", "idle", idle)
+	}
+
+	shutdownTimeout := getEnvDuration("SHUTDOWN_TIMEOUT", 15*time.Second)
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-stopCtx.Done()
+		slog.Info("Server dihentikan, menunggu request berjalan selesai", "timeout", shutdownTimeout)
+		c, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(c); err != nil {
+			slog.Error("Gagal mematikan server dengan bersih", "err", err)
+		}
+	}()
+
+	// Dependensi disambungkan setelah listener terbuka: selama initDB mencoba ulang, /livez
+	// sudah menjawab 200 sementara /readyz tetap 503 sampai semua koneksi berhasil
+	go func() {
+		app.initDB()
+		app.initRedis()
+		initLocalListCache()
+		if n := getEnvInt("WARM_TOP_N", 100); n > 0 {
+			go app.warmTopProducts(context.Background(), n)
+		}
+		app.startPoolMonitor(
+			getEnvDuration("DB_POOL_MONITOR_INTERVAL", 10*time.Second),
+			getEnvDuration("DB_POOL_WAIT_THRESHOLD", 100*time.Millisecond),
+		)
+		if writeQueueEnabled {
+			app.startDBProbe()
+			app.startWriteQueueWorker(r)
+			slog.Info("Antrean tulis saat failover database aktif")
+		}
+		markReady()
+		slog.Info("Dependensi tersambung, server siap menerima trafik")
+	}()
+
+	// Alamat dari listener, bukan dari env: port ":0" sudah terisi port yang dipilih kernel
+	slog.Info("Server berjalan", "addr", ln.Addr().String(), "network", ln.Addr().Network())
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		cleanupListener()
+		log.Fatal(err)
+	}
+	<-shutdownDone
+	cleanupListener()
+	runShutdownHooks(getEnvDuration("SHUTDOWN_DRAIN_TIMEOUT", 10*time.Second))
+	// Dihentikan sebelum startup selesai: koneksi mungkin belum dibuat, proses keluar saja
+	if isReady() {
+		if err := app.rdb.Close(); err != nil {
+			slog.Error("Gagal menutup koneksi Redis", "err", err)
+		}
+		if err := app.db.Close(); err != nil {
+			slog.Error("Gagal menutup koneksi database", "err", err)
+		}
+	}
+	slog.Info("Server berhenti")
 }
 
+// Batas ukuran halaman daftar produk; limit di atas maxListLimit dipotong, bukan ditolak
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
 // --- PERUBAHAN UTAMA DI SINI ---
 // Fungsi handleGetProducts sekarang menerima parameter paginasi
 
-func handleGetProducts(w http.ResponseWriter, r *http.Request, marshaller func(v interface{}) ([]byte, error)) {
-	// 1. Baca parameter 'limit' dan 'page' dari URL
+func (a *App) handleGetProducts(w http.ResponseWriter, r *http.Request, marshaller func(v interface{}) ([]byte, error)) {
+	// 1. Baca parameter 'limit' dan 'page' (atau 'offset') dari URL
 	limitStr := r.URL.Query().Get("limit")
 	pageStr := r.URL.Query().Get("page")
 
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil || limit <= 0 {
-		limit = 50 // Nilai default jika tidak ada atau tidak valid
+		limit = defaultListLimit // Nilai default jika tidak ada atau tidak valid
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
 	}
 
 	page, err := strconv.Atoi(pageStr)
@@ -75,157 +300,868 @@ func handleGetProducts(w http.ResponseWriter, r *http.Request, marshaller func(v
 		page = 1 // Nilai default
 	}
 
+	// offset eksplisit mengalahkan page; halaman untuk header Link dihitung darinya
 	offset := (page - 1) * limit
+	if o, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && o >= 0 {
+		offset = o
+		page = offset/limit + 1
+	}
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	// Buat kunci cache yang unik untuk setiap halaman
-	cacheKey := fmt.Sprintf("products:page:%d:limit:%d", page, limit)
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Add("Vary", "Accept")
 
-	// Logika caching tetap sama
-	cachedProducts, err := rdb.Get(ctx, cacheKey).Result()
-	if err == nil {
-		log.Printf("CACHE HIT: Mengambil dari Redis untuk kunci %s.", cacheKey)
-		w.Header().Set("Content-Type", "application/json")
-		w.Write([]byte(cachedProducts))
+	// Buat kunci cache yang unik untuk setiap halaman dan kombinasi filter
+	cacheKey := fmt.Sprintf("products:limit=%d:offset=%d", limit, offset) + filter.cacheKey()
+
+	// Admin mendapat serialisasi terpisah (cost & margin) langsung dari DB, tanpa cache/ETag
+	if isAdmin(r) {
+		products, err := a.fetchProductsFromDB(r.Context(), filter, limit, offset)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		a.setPaginationLinks(w, r, filter, page, limit)
+		writeAdminProducts(w, products, marshaller)
 		return
 	}
 
-	// 2. Ambil data dari DB dengan LIMIT dan OFFSET
-	log.Printf("CACHE MISS: Mengambil dari PostgreSQL untuk kunci %s.", cacheKey)
-	products, err := fetchProductsFromDB(limit, offset) // Panggil fungsi yang diperbarui
+	// Admin selalu JSON (cost/margin tidak ada di skema protobuf)
+	if wantsProtobuf(r) {
+		marshaller = marshalProductsProto
+		cacheKey += ":format=pb"
+	}
+
+	// Read-your-writes: sesaat setelah penulisan, daftar dibaca dari DB dan tidak di-cache
+	if a.listCacheBypassed(r.Context()) {
+		slog.DebugContext(r.Context(), "CACHE BYPASS: Penulisan baru terjadi, mengambil dari PostgreSQL", "key", cacheKey)
+		cacheKey = ""
+	}
+
+	// Logika caching tetap sama
+	if cacheKey != "" {
+		verify, sync := cacheVerifyMode(r)
+		// ETag klien yang polling dicocokkan dengan ETag tersimpan, tanpa membaca body maupun
+		// menghitung hash ulang. Verifikasi sinkron bisa mengganti body, jadi 304 menunggu hasilnya.
+		etag, _ := a.rdb.Get(r.Context(), cacheKey+listCacheETagSuffix).Result()
+		if etag != "" && !(verify && sync) && writeNotModified(w, r, etag) {
+			return
+		}
+		if !verify && a.writeGzipListCache(w, r, cacheKey, filter, page, limit) {
+			return
+		}
+		cachedProducts, err := a.rdb.Get(r.Context(), cacheKey).Result()
+		if local, ok := getLocalListCache(cacheKey, err); ok {
+			slog.WarnContext(r.Context(), "CACHE HIT (lokal): Redis tidak tersedia, memakai salinan di memori", "key", cacheKey, "err", err)
+			if writeNotModified(w, r, listETag(local)) {
+				return
+			}
+			a.setPaginationLinks(w, r, filter, page, limit)
+			w.Header().Set("Content-Type", productContentType(r))
+			w.Write(local)
+			return
+		}
+		if err == nil {
+			slog.DebugContext(r.Context(), "CACHE HIT: Mengambil dari Redis", "key", cacheKey)
+			body := []byte(cachedProducts)
+			setLocalListCache(cacheKey, body)
+			if verify && sync {
+				body = a.verifyListCache(r.Context(), cacheKey, body, filter, limit, offset, marshaller)
+				etag = ""
+			} else if verify {
+				verifyInBackground(func(c context.Context) {
+					a.verifyListCache(c, cacheKey, body, filter, limit, offset, marshaller)
+				})
+			}
+			if etag == "" {
+				etag = listETag(body)
+			}
+			if writeNotModified(w, r, etag) {
+				return
+			}
+			a.setPaginationLinks(w, r, filter, page, limit)
+			w.Header().Set("Content-Type", productContentType(r))
+			w.Write(body)
+			return
+		}
+		// 2. Ambil data dari DB dengan LIMIT dan OFFSET
+		slog.DebugContext(r.Context(), "CACHE MISS: Mengambil dari PostgreSQL", "key", cacheKey)
+	}
+	if getBoolQuery(r, "best_effort") {
+		products, partial, err := a.fetchProductsBestEffort(r.Context(), filter, limit, offset)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if partial {
+			writePartialProducts(w, r, products, marshaller)
+			return
+		}
+		// Hasil lengkap diperlakukan sama seperti jalur normal (termasuk disimpan ke cache)
+		a.writeProductList(w, r, cacheKey, products, filter, page, limit, marshaller)
+		return
+	}
+	products, shared, err := a.fetchProductsShared(r.Context(), cacheKey, filter, limit, offset)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if shared {
+		// Hasil milik pemimpin singleflight; cukup dia yang menulis cache
+		cacheKey = ""
+	}
+	a.writeProductList(w, r, cacheKey, products, filter, page, limit, marshaller)
+}
+
+// Request yang cache-miss pada kunci daftar yang sama berbagi satu query database,
+// agar kedaluwarsanya satu kunci populer tidak berubah menjadi lonjakan query (stampede).
+var productListFlight singleflight.Group
 
+// fetchProductsShared menjalankan fetchProductsFromDB lewat singleflight per kunci cache.
+// shared bernilai true bila hasilnya berasal dari query milik request lain. Query memakai context
+// tanpa pembatalan, supaya klien pemimpin yang terputus tidak menggagalkan yang menunggu.
+// Tanpa kunci cache (bypass read-your-writes) setiap request membaca DB sendiri.
+func (a *App) fetchProductsShared(c context.Context, cacheKey string, filter productFilter, limit, offset int) ([]Product, bool, error) {
+	if cacheKey == "" {
+		products, err := a.fetchProductsFromDB(c, filter, limit, offset)
+		return products, false, err
+	}
+	// Do juga melaporkan shared=true kepada pemimpin, jadi pemimpin ditandai sendiri.
+	// WithoutCancel juga membuang deadline request; batasnya tinggal dbQueryTimeout.
+	leader := false
+	v, err, _ := productListFlight.Do(cacheKey, func() (interface{}, error) {
+		leader = true
+		return a.fetchProductsFromDB(context.WithoutCancel(c), filter, limit, offset)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return v.([]Product), !leader, nil
+}
+
+// writeProductList menyimpan daftar produk beserta ETag-nya ke cache (kecuali cacheKey kosong)
+// lalu mengirimkannya, atau 304 bila If-None-Match klien cocok
+func (a *App) writeProductList(w http.ResponseWriter, r *http.Request, cacheKey string, products []Product, filter productFilter, page, limit int, marshaller func(v interface{}) ([]byte, error)) {
 	// 3. Simpan ke cache dan kirim respons (logika ini tetap sama)
 	jsonData, err := marshaller(products)
 	if err != nil {
-		http.Error(w, "Gagal mem-format data untuk cache", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mem-format data untuk cache")
 		return
 	}
-	err = rdb.Set(ctx, cacheKey, jsonData, 10*time.Minute).Err()
-	if err != nil {
-		log.Printf("Gagal menyimpan ke Redis: %v", err)
+	etag := listETag(jsonData)
+	if cacheKey != "" {
+		ttl := productListCacheTTL(products)
+		a.setListCache(r.Context(), cacheKey, jsonData, ttl)
+		a.setListCache(r.Context(), cacheKey+listCacheETagSuffix, []byte(etag), ttl)
+		setLocalListCache(cacheKey, jsonData)
+		if gzipEnabled && len(jsonData) >= gzipMinBytes {
+			// Salinan terkompresi agar cache hit tidak mengompresi ulang; ikut terhapus oleh "products:*"
+			if gz, err := gzipBytes(jsonData); err == nil {
+				a.setListCache(r.Context(), cacheKey+listCacheGzipSuffix, gz, ttl)
+			}
+		}
 	}
-	w.Header().Set("Content-Type", "application/json")
+	if writeNotModified(w, r, etag) {
+		return
+	}
+	a.setPaginationLinks(w, r, filter, page, limit)
+	w.Header().Set("Content-Type", productContentType(r))
 	w.Write(jsonData)
 }
 
-// Fungsi fetchProductsFromDB sekarang menerima limit dan offset
-func fetchProductsFromDB(limit, offset int) ([]Product, error) {
+// Akhiran kunci cache daftar untuk salinan gzip dari payload yang sama
+const listCacheGzipSuffix = ":gz"
+
+// writeGzipListCache mengirim salinan gzip dari cache daftar bila klien menerimanya.
+// Dilewati untuk envelope karena body harus dibungkus dulu; pemanggil juga melewatinya saat
+// verifikasi cache, yang membutuhkan body asli.
+func (a *App) writeGzipListCache(w http.ResponseWriter, r *http.Request, cacheKey string, filter productFilter, page, limit int) bool {
+	if !gzipEnabled || !acceptsGzip(r) || wantsEnvelope(r) {
+		return false
+	}
+	gz, err := a.rdb.Get(r.Context(), cacheKey+listCacheGzipSuffix).Bytes()
+	if err != nil {
+		return false
+	}
+	slog.DebugContext(r.Context(), "CACHE HIT (gzip): Mengambil dari Redis", "key", cacheKey)
+	a.setPaginationLinks(w, r, filter, page, limit)
+	w.Header().Set("Content-Type", productContentType(r))
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Write(gz)
+	return true
+}
+
+// setPaginationLinks menulis header X-Total-Count dan Link dengan rel first/prev/next/last.
+// Parameter query lain (selain page) dipertahankan pada setiap URL. Total dibaca dari
+// cache hitungan (fetchProductCount), jadi tidak menambah query pada setiap request.
+func (a *App) setPaginationLinks(w http.ResponseWriter, r *http.Request, filter productFilter, page, limit int) {
+	total, err := a.fetchProductCount(r.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Gagal menghitung total produk untuk header X-Total-Count", "err", err)
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	if !paginationLinks {
+		return
+	}
+	lastPage := (total + limit - 1) / limit
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	pageURL := func(p int) string {
+		q := r.URL.Query()
+		q.Del("offset")
+		q.Set("page", strconv.Itoa(p))
+		q.Set("limit", strconv.Itoa(limit))
+		return r.URL.Path + "?" + q.Encode()
+	}
+
+	links := []string{fmt.Sprintf(`<%s>; rel="first"`, pageURL(1))}
+	if page > 1 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(page-1)))
+	}
+	if page < lastPage {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(page+1)))
+	}
+	links = append(links, fmt.Sprintf(`<%s>; rel="last"`, pageURL(lastPage)))
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+// fetchProductCount mengembalikan jumlah produk yang cocok dengan filter, di-cache di Redis
+func (a *App) fetchProductCount(c context.Context, filter productFilter) (int, error) {
+	cacheKey := cacheKeyProductCount + filter.cacheKey()
+	if total, err := a.rdb.Get(c, cacheKey).Int(); err == nil {
+		return total, nil
+	}
+	where, args := filter.where(nil)
+	c, cancel := withQueryTimeout(c)
+	defer cancel()
+	var total int
+	err := withRetry(c, func(c context.Context) error {
+		return a.db.QueryRowContext(c, `SELECT COUNT(*) FROM products p`+where, args...).Scan(&total)
+	})
+	if err != nil {
+		return 0, errors.New("gagal menghitung jumlah produk")
+	}
+	a.setListCache(c, cacheKey, total, cacheTTL)
+	return total, nil
+}
+
+// Fungsi fetchProductsFromDB sekarang menerima filter, limit dan offset
+func (a *App) fetchProductsFromDB(c context.Context, filter productFilter, limit, offset int) ([]Product, error) {
+	sqlStatement, args := productListQuery(filter, limit, offset)
+	products, err := a.queryProducts(c, sqlStatement, args...)
+	if err != nil {
+		return nil, err
+	}
+	localizeProducts(products, filter.Locales)
+	return products, nil
+}
+
+// productListQuery menyusun query satu halaman daftar produk
+func productListQuery(filter productFilter, limit, offset int) (string, []interface{}) {
 	// 3. Query SQL sekarang menggunakan LIMIT dan OFFSET
-	sqlStatement := `SELECT id, name, price, stock FROM products ORDER BY id LIMIT $1 OFFSET $2`
-	rows, err := db.Query(sqlStatement, limit, offset)
+	where, args := filter.where(nil)
+	args = append(args, limit, offset)
+	sqlStatement := `SELECT ` + productColumns + ` FROM products p` + where +
+		filter.orderBy() + fmt.Sprintf(` LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+	return sqlStatement, args
+}
+
+// Batas waktu satu query atau satu transaksi (DB_QUERY_TIMEOUT), di bawah batas waktu request
+// dari timeoutMiddleware. Berlaku untuk baca maupun tulis, juga untuk query tanpa context request
+// (singleflight, pemanasan cache), sehingga Postgres yang lambat tidak menahan koneksi pool tanpa
+// batas. Transaksi memakai satu batas untuk BeginTx sampai Commit.
+var dbQueryTimeout = 5 * time.Second
+
+// withQueryTimeout menurunkan context dengan dbQueryTimeout; 0 berarti hanya batas milik c
+func withQueryTimeout(c context.Context) (context.Context, context.CancelFunc) {
+	if dbQueryTimeout <= 0 {
+		return context.WithCancel(c)
+	}
+	return context.WithTimeout(c, dbQueryTimeout)
+}
+
+// queryProducts menjalankan query produk dan memindai hasilnya. Saat error, baris yang
+// sudah terpindai tetap dikembalikan (dipakai oleh mode best-effort).
+func (a *App) queryProducts(c context.Context, sqlStatement string, args ...interface{}) ([]Product, error) {
+	c, cancel := withQueryTimeout(c)
+	defer cancel()
+	products := make([]Product, 0)
+	// Hanya pembukaan query yang diulang; error di tengah iterasi mengembalikan baris parsial
+	var rows *sql.Rows
+	err := withRetry(c, func(c context.Context) error {
+		var err error
+		rows, err = a.db.QueryContext(c, sqlStatement, args...)
+		return err
+	})
 	if err != nil {
-		return nil, errors.New("gagal mengambil daftar produk")
+		return products, errors.New("gagal mengambil daftar produk")
 	}
 	defer rows.Close()
 
-	var products []Product
 	for rows.Next() {
 		var p Product
-		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock); err != nil {
-			return nil, errors.New("gagal memindai data produk")
+		if err := scanProduct(rows, &p); err != nil {
+			return products, errors.New("gagal memindai data produk")
 		}
 		products = append(products, p)
 	}
 	if err = rows.Err(); err != nil {
-		return nil, errors.New("error saat iterasi produk")
-	}
-	if products == nil {
-		products = make([]Product, 0)
+		return products, errors.New("error saat iterasi produk")
 	}
 	return products, nil
 }
 
 // Handler pembanding (tidak berubah)
-func getProductsStandardHandler(w http.ResponseWriter, r *http.Request) {
-	handleGetProducts(w, r, json.Marshal)
+func (a *App) getProductsStandardHandler(w http.ResponseWriter, r *http.Request) {
+	a.handleGetProducts(w, r, json.Marshal)
 }
 
-func getProductsIteratorHandler(w http.ResponseWriter, r *http.Request) {
-	handleGetProducts(w, r, jsoni.Marshal)
+func (a *App) getProductsIteratorHandler(w http.ResponseWriter, r *http.Request) {
+	a.handleGetProducts(w, r, jsoni.Marshal)
 }
 
 // Ditambahkan di sini agar file lengkap
-func createProductHandler(w http.ResponseWriter, r *http.Request) {
-	var p Product
-	if err := jsoni.NewDecoder(r.Body).Decode(&p); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+func (a *App) createProductHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := readRequestBody(w, r)
+	if err != nil {
+		writeBodyError(w, err)
 		return
 	}
-	sqlStatement := `INSERT INTO products (name, price, stock) VALUES ($1, $2, $3) RETURNING id`
-	err := db.QueryRow(sqlStatement, p.Name, p.Price, p.Stock).Scan(&p.ID)
+	p, err := decodeNewProduct(bytes.NewReader(body))
 	if err != nil {
-		http.Error(w, "Gagal membuat produk", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validateProduct(p); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validatePrice(p.Price); err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
 		return
 	}
-	// Invalidate: Lebih kompleks dengan paginasi, untuk sekarang kita biarkan
+	attrs, err := normalizeNewProduct(&p)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Atribut produk tidak valid")
+		return
+	}
+	// RETURNING memakai productColumns agar body respons persis sama dengan yang tersimpan,
+	// termasuk default dari database
+	sqlStatement := `INSERT INTO products AS p (name, price, stock, category, sku, tags, attributes) VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7) RETURNING ` + productColumns
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	err = scanProduct(a.db.QueryRowContext(qc, sqlStatement, p.Name, p.Price, p.Stock, p.Category, p.SKU, pq.Array(p.Tags), attrs), &p)
+	if err != nil {
+		if isPQError(err, pqUniqueViolation) {
+			writeJSONError(w, http.StatusConflict, "SKU sudah dipakai")
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "Gagal membuat produk")
+		return
+	}
+	a.afterProductCreated(r.Context(), &p)
+	writeCreatedProduct(w, p)
+}
+
+// writeCreatedProduct mengirim 201 dengan Location ke resource produk yang baru dibuat
+func writeCreatedProduct(w http.ResponseWriter, p Product) {
+	w.Header().Set("Location", "/products/"+strconv.Itoa(p.ID))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	jsoni.NewEncoder(w).Encode(p)
 }
-func updateStockHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, _ := strconv.Atoi(vars["id"])
+
+// normalizeNewProduct mengisi nilai default produk baru dan mengembalikan atribut siap simpan
+func normalizeNewProduct(p *Product) ([]byte, error) {
+	if p.Attributes == nil {
+		p.Attributes = SortedMap{}
+	}
+	p.Category = strings.TrimSpace(p.Category)
+	if p.Category == "" {
+		p.Category = productDefaults.Category
+	}
+	p.SKU = strings.TrimSpace(p.SKU)
+	p.Tags = normalizeTags(p.Tags)
+	return jsoni.Marshal(p.Attributes)
+}
+
+// afterProductCreated membersihkan cache yang terdampak produk baru
+func (a *App) afterProductCreated(c context.Context, p *Product) {
+	// Hapus sentinel 404 (dan cache stok) yang mungkin tersimpan untuk id ini
+	a.invalidateProductCaches(c, p.ID, p.Category)
+	// Produk baru belum memiliki varian
+	p.AvailableStock = p.Stock
+	p.Available = p.Stock > 0
+}
+
+// updateStockHandler menerima {"stock": N} (nilai absolut) atau {"delta": -3} (relatif).
+// Delta dihitung dari baris yang dikunci FOR UPDATE, sehingga perubahan relatif yang
+// bersamaan tidak saling menimpa, dan ditolak bila stok akan menjadi negatif.
+func (a *App) updateStockHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "id produk tidak valid")
+		return
+	}
 	var payload struct {
-		Stock int `json:"stock"`
+		Stock   *int `json:"stock"`
+		Delta   *int `json:"delta"`
+		Version int  `json:"version"`
+	}
+	if err := jsoni.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
 	}
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if (payload.Stock == nil) == (payload.Delta == nil) {
+		writeJSONError(w, http.StatusBadRequest, "Isi salah satu dari stock atau delta")
 		return
 	}
-	sqlStatement := `UPDATE products SET stock = $1 WHERE id = $2`
-	_, err := db.Exec(sqlStatement, payload.Stock, id)
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	tx, err := a.db.BeginTx(qc, nil)
 	if err != nil {
-		http.Error(w, "Gagal memperbarui stok", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui stok")
+		return
+	}
+	defer tx.Rollback()
+	if ok, err := ifMatchProduct(qc, tx, r, id); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui stok")
+		return
+	} else if !ok {
+		writeJSONError(w, http.StatusPreconditionFailed, "Produk telah berubah (If-Match tidak cocok)")
+		return
+	}
+	// Stok lama selalu dibaca (dan dikunci) untuk audit_log, tidak hanya untuk delta
+	var current int
+	var threshold sql.NullInt64
+	err = tx.QueryRowContext(qc, `SELECT stock, low_stock_threshold FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).Scan(&current, &threshold)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSONError(w, http.StatusNotFound, "Produk tidak ditemukan")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui stok")
+		return
+	}
+	newStock := payload.Stock
+	if payload.Delta != nil {
+		n := current + *payload.Delta
+		if n < 0 {
+			writeJSONError(w, http.StatusConflict, "Stok tidak mencukupi, tersisa "+strconv.Itoa(current))
+			return
+		}
+		newStock = &n
+	}
+	sqlStatement := `UPDATE products SET stock = $1 WHERE id = $2 AND deleted_at IS NULL AND ($3 = 0 OR version = $3) RETURNING category, updated_at`
+	var category string
+	var updatedAt time.Time
+	err = tx.QueryRowContext(qc, sqlStatement, *newStock, id, payload.Version).Scan(&category, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Baris sudah dikunci di atas, jadi satu-satunya penyebab adalah version yang berbeda
+		writeVersionConflict(w, qc, tx, id)
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui stok")
+		return
+	}
+	if err := recordAudit(qc, tx, id, stockChange(current, *newStock)); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mencatat riwayat stok")
 		return
 	}
+	if err := tx.Commit(); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui stok")
+		return
+	}
+	a.invalidateProductCaches(r.Context(), id, category)
+	a.notifyLowStock(r.Context(), id, current, *newStock, threshold)
+	w.Header().Set("ETag", productETag(updatedAt))
 	w.WriteHeader(http.StatusOK)
 }
 
-func getProductHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, _ := strconv.Atoi(vars["id"])
+// updateProductHandler mengganti name, price, dan stock produk lalu mengembalikan produk terbaru.
+// Mendukung If-Match dan field version untuk optimistic locking.
+func (a *App) updateProductHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "id produk tidak valid")
+		return
+	}
+	body, err := readRequestBody(w, r)
+	if err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	var payload Product
+	dec := jsoni.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&payload); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validateProduct(payload); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validatePrice(payload.Price); err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	tx, err := a.db.BeginTx(qc, nil)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui produk")
+		return
+	}
+	defer tx.Rollback()
+	if ok, err := ifMatchProduct(qc, tx, r, id); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui produk")
+		return
+	} else if !ok {
+		writeJSONError(w, http.StatusPreconditionFailed, "Produk telah berubah (If-Match tidak cocok)")
+		return
+	}
+	var old Product
+	err = tx.QueryRowContext(qc, `SELECT name, price, stock FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).Scan(&old.Name, &old.Price, &old.Stock)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui produk")
+		return
+	}
+	res, err := tx.ExecContext(qc, `UPDATE products SET name=$1, price=$2, stock=$3 WHERE id=$4 AND deleted_at IS NULL AND ($5 = 0 OR version = $5)`,
+		payload.Name, payload.Price, payload.Stock, id, payload.Version)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui produk")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		writeVersionConflict(w, qc, tx, id)
+		return
+	}
+	err = recordAudit(qc, tx, id,
+		auditChange{Field: "name", OldValue: old.Name, NewValue: payload.Name},
+		auditChange{Field: "price", OldValue: old.Price.String(), NewValue: payload.Price.String()},
+		stockChange(old.Stock, payload.Stock),
+	)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mencatat riwayat produk")
+		return
+	}
 	var p Product
-	sqlStatement := `SELECT id, name, price, stock FROM products WHERE id=$1`
-	err := db.QueryRow(sqlStatement, id).Scan(&p.ID, &p.Name, &p.Price, &p.Stock)
+	if err := scanProduct(tx.QueryRowContext(qc, `SELECT `+productColumns+` FROM products p WHERE p.id=$1`, id), &p); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil produk")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui produk")
+		return
+	}
+
+	a.invalidateProductCaches(r.Context(), id, p.Category)
+	w.Header().Set("ETag", productETag(p.UpdatedAt.Time))
+	w.Header().Set("Content-Type", "application/json")
+	jsoni.NewEncoder(w).Encode(p)
+}
+
+// deleteProductHandler menghapus produk secara soft delete (deleted_at); varian tetap tersimpan
+func (a *App) deleteProductHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			http.NotFound(w, r)
-		} else {
-			http.Error(w, "Gagal mengambil produk", http.StatusInternalServerError)
-		}
+		writeJSONError(w, http.StatusBadRequest, "id produk tidak valid")
+		return
+	}
+	// Soft delete: baris tetap ada untuk riwayat, tetapi tidak lagi terlihat oleh query baca
+	var category string
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	err = a.db.QueryRowContext(qc, `UPDATE products SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL RETURNING category`, id).Scan(&category)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal menghapus produk")
+		return
+	}
+	a.invalidateProductCaches(r.Context(), id, category)
+	if err := a.rdb.ZRem(r.Context(), productPopularityKey, strconv.Itoa(id)).Err(); err != nil {
+		slog.ErrorContext(r.Context(), "Gagal menghapus popularitas produk", "product_id", id, "err", err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// updateAttributesHandler mengganti seluruh objek atribut produk
+func (a *App) updateAttributesHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "id produk tidak valid")
+		return
+	}
+	var attributes SortedMap
+	if err := jsoni.NewDecoder(r.Body).Decode(&attributes); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if attributes == nil {
+		attributes = SortedMap{}
+	}
+	attrs, err := jsoni.Marshal(attributes)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Atribut produk tidak valid")
+		return
+	}
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	tx, err := a.db.BeginTx(qc, nil)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui atribut")
+		return
+	}
+	defer tx.Rollback()
+	if ok, err := ifMatchProduct(qc, tx, r, id); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui atribut")
+		return
+	} else if !ok {
+		writeJSONError(w, http.StatusPreconditionFailed, "Produk telah berubah (If-Match tidak cocok)")
+		return
+	}
+	var updatedAt time.Time
+	err = tx.QueryRowContext(qc, `UPDATE products SET attributes = $1 WHERE id = $2 AND deleted_at IS NULL RETURNING updated_at`, attrs, id).Scan(&updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+		return
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui atribut")
 		return
 	}
+	a.invalidateProductCaches(r.Context(), id)
+	w.Header().Set("ETag", productETag(updatedAt))
 	w.Header().Set("Content-Type", "application/json")
+	jsoni.NewEncoder(w).Encode(attributes)
+}
+
+func (a *App) getProductHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "id produk tidak valid")
+		return
+	}
+	cacheKey := productCacheKey(id)
+
+	// Cache per id (product:{id}, TTL sama dengan daftar); dihapus oleh setiap handler tulis
+	// lewat invalidateProductCache
+	p, hit, notFound := a.cachedProduct(r.Context(), id)
+	// Negative cache: id yang baru saja tidak ditemukan langsung dijawab 404 dari Redis
+	if hit && notFound && negativeCacheTTL > 0 {
+		slog.DebugContext(r.Context(), "CACHE HIT (404): Produk tidak ada menurut Redis", "key", cacheKey)
+		verify, sync := cacheVerifyMode(r)
+		if verify && !sync {
+			verifyInBackground(func(c context.Context) { a.verifyNegativeCache(c, id) })
+		}
+		// Pada mode sinkron, sentinel yang salah dihapus dan produk dibaca dari DB di bawah
+		if !sync || !a.verifyNegativeCache(r.Context(), id) {
+			writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+			return
+		}
+	}
+
+	if hit && !notFound {
+		slog.DebugContext(r.Context(), "CACHE HIT: Mengambil dari Redis", "key", cacheKey)
+	} else {
+		slog.DebugContext(r.Context(), "CACHE MISS: Mengambil dari PostgreSQL", "key", cacheKey)
+		sqlStatement := `SELECT ` + productColumns + ` FROM products p WHERE p.id=$1 AND p.deleted_at IS NULL`
+		qc, cancel := withQueryTimeout(r.Context())
+		err := withRetry(qc, func(c context.Context) error {
+			return scanProduct(a.db.QueryRowContext(c, sqlStatement, id), &p)
+		})
+		cancel()
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				if negativeCacheTTL > 0 {
+					if err := a.rdb.Set(r.Context(), cacheKey, cacheNilSentinel, negativeCacheTTL).Err(); err != nil {
+						slog.ErrorContext(r.Context(), "Gagal menyimpan ke Redis", "err", err)
+					}
+				}
+				writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+			} else {
+				writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil produk")
+			}
+			return
+		}
+		a.cacheProduct(r.Context(), p)
+	}
+	a.recordProductView(r.Context(), id)
+	localizeProduct(&p, parseAcceptLanguage(r.Header.Get("Accept-Language")))
+	w.Header().Add("Vary", "Accept-Language")
+	if p.Locale != "" {
+		w.Header().Set("Content-Language", p.Locale)
+	}
+	w.Header().Set("ETag", productETag(p.UpdatedAt.Time))
+	w.Header().Set("Content-Type", "application/json")
+	if isAdmin(r) {
+		w.Header().Set("Cache-Control", "private, no-store")
+		jsoni.NewEncoder(w).Encode(newAdminProduct(p))
+		return
+	}
+	if wantsProtobuf(r) {
+		data, err := marshalProductProto(p)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Gagal mem-format data produk")
+			return
+		}
+		w.Header().Add("Vary", "Accept")
+		w.Header().Set("Content-Type", protobufMediaType)
+		w.Write(data)
+		return
+	}
+	if getBoolQuery(r, "stock_breakdown") {
+		bp, err := a.withStockBreakdown(r.Context(), p)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Gagal membaca reservasi produk", "product_id", id, "err", err)
+			writeJSONError(w, http.StatusInternalServerError, "Gagal menghitung stok tersedia")
+			return
+		}
+		jsoni.NewEncoder(w).Encode(bp)
+		return
+	}
 	jsoni.NewEncoder(w).Encode(p)
 }
 
-func initDB(connStr string) {
+// newRouter memasang middleware dan semua route. Dipisah dari main agar test integrasi
+// memakai router yang sama persis dengan server.
+func (a *App) newRouter(trailingSlash string, writeQueueEnabled bool) *mux.Router {
+	r := mux.NewRouter()
+	r.StrictSlash(trailingSlash == trailingSlashRedirect)
+	r.Use(requestIDMiddleware)
+	r.Use(loggingMiddleware)
+	// Setelah logging agar request yang panic tetap tercatat dengan status 500
+	r.Use(recoverMiddleware)
+	r.Use(rateLimitMiddleware(loadRateLimitConfig()))
+	r.Use(queryCountMiddleware)
+	r.Use(timeoutMiddleware(loadTimeoutConfig()))
+	r.Use(securityHeadersMiddleware(loadSecurityHeadersConfig()))
+	if getEnvBool("REQUIRE_CONTENT_LENGTH", false) {
+		r.Use(requireContentLengthMiddleware)
+	}
+	gzipEnabled = getEnvBool("GZIP_ENABLED", gzipEnabled)
+	gzipMinBytes = getEnvInt("GZIP_MIN_BYTES", gzipMinBytes)
+	if gzipEnabled {
+		// Di luar envelope agar yang dikompresi adalah body final
+		r.Use(gzipMiddleware)
+	}
+	r.Use(envelopeMiddleware)
+	if writeQueueEnabled {
+		r.Use(a.writeQueueMiddleware)
+	}
+	r.HandleFunc("/admin/search/reindex", requireAdmin(limitBulk(a.reindexSearchHandler))).Methods("POST").Name("admin-search-reindex")
+	r.HandleFunc("/admin/cache/preview", requireAdmin(a.previewCacheInvalidationHandler)).Methods("POST").Name("admin-cache-preview")
+	r.HandleFunc("/admin/cache/latency", requireAdmin(cacheLatencyHandler)).Methods("GET").Name("admin-cache-latency")
+	r.HandleFunc("/metrics", metricsHandler).Methods("GET").Name("metrics")
+	r.HandleFunc("/operations/{id}", a.getOperationHandler).Methods("GET").Name("get-operation")
+	r.HandleFunc("/openapi.json", openAPIHandler).Methods("GET").Name("openapi")
+	r.HandleFunc("/docs", docsHandler).Methods("GET").Name("docs")
+	r.HandleFunc("/docs/init.js", docsInitHandler).Methods("GET").Name("docs-init")
+	r.HandleFunc("/healthz", a.healthHandler).Methods("GET").Name("healthz")
+	r.HandleFunc("/livez", livezHandler).Methods("GET").Name("livez")
+	r.HandleFunc("/readyz", a.readyHandler).Methods("GET").Name("readyz")
+	r.HandleFunc("/products-standard", a.getProductsStandardHandler).Methods("GET").Name("list-products-standard")
+	r.HandleFunc("/products-iterator", a.getProductsIteratorHandler).Methods("GET").Name("list-products-iterator")
+	r.HandleFunc("/products", a.idempotent(a.createProductHandler)).Methods("POST").Name("create-product")
+	r.HandleFunc("/products.jsonl", a.exportJSONLinesHandler).Methods("GET").Name("export-jsonl")
+	r.HandleFunc("/products/export", a.exportProductsHandler).Methods("GET").Name("export-products")
+	r.HandleFunc("/products/compare", a.compareProductsHandler).Methods("GET").Name("compare-products")
+	r.HandleFunc("/products/availability", a.checkAvailabilityHandler).Methods("POST").Name("check-availability")
+	r.HandleFunc("/products/batch", limitBulk(a.createProductsBatchHandler)).Methods("POST").Name("create-products-batch")
+	r.HandleFunc("/products/tags", limitBulk(a.bulkTagHandler)).Methods("POST").Name("bulk-tags")
+	r.HandleFunc("/products/stock-levels", a.stockLevelsHandler).Methods("POST").Name("stock-levels")
+	r.HandleFunc("/products/holds", a.createHoldHandler).Methods("POST").Name("create-hold")
+	r.HandleFunc("/holds/{token}", a.getHoldHandler).Methods("GET").Name("get-hold")
+	r.HandleFunc("/holds/{token}", a.cancelHoldHandler).Methods("DELETE").Name("cancel-hold")
+	r.HandleFunc("/holds/{token}/confirm", a.confirmHoldHandler).Methods("POST").Name("confirm-hold")
+	r.HandleFunc("/products/grouped", a.groupedProductsHandler).Methods("GET").Name("grouped-products")
+	r.HandleFunc("/products/random", a.randomProductHandler).Methods("GET").Name("random-product")
+	r.HandleFunc("/products/sku/{sku}", a.putProductBySKUHandler).Methods("PUT").Name("put-product-by-sku")
+	r.HandleFunc("/products/{id:[0-9]+}", a.getProductHandler).Methods("GET").Name("get-product")
+	r.HandleFunc("/products/{id:[0-9]+}", a.updateProductHandler).Methods("PUT").Name("update-product")
+	r.HandleFunc("/products/{id:[0-9]+}", a.deleteProductHandler).Methods("DELETE").Name("delete-product")
+	r.HandleFunc("/products/{id:[0-9]+}/history", a.productHistoryHandler).Methods("GET").Name("product-history")
+	r.HandleFunc("/products/{id:[0-9]+}/stock", a.updateStockHandler).Methods("PUT").Name("update-stock")
+	// Retry checkout dengan Idempotency-Key yang sama tidak mengurangi stok dua kali
+	r.HandleFunc("/products/{id:[0-9]+}/reserve", a.idempotent(a.reserveStockHandler)).Methods("POST").Name("reserve-stock")
+	r.HandleFunc("/products/{id:[0-9]+}/cost", requireAdmin(a.updateCostHandler)).Methods("PUT").Name("update-cost")
+	r.HandleFunc("/products/{id:[0-9]+}/low-stock-threshold", requireAdmin(a.updateLowStockThresholdHandler)).Methods("PUT").Name("update-low-stock-threshold")
+	r.HandleFunc("/products/{id:[0-9]+}/cache-ttl", requireAdmin(a.updateCacheTTLHandler)).Methods("PUT").Name("update-cache-ttl")
+	r.HandleFunc("/products/{id:[0-9]+}/attributes", a.updateAttributesHandler).Methods("PUT").Name("update-attributes")
+	r.HandleFunc("/products/{id:[0-9]+}/translations", a.listTranslationsHandler).Methods("GET").Name("list-translations")
+	r.HandleFunc("/products/{id:[0-9]+}/translations/{locale}", a.putTranslationHandler).Methods("PUT").Name("put-translation")
+	r.HandleFunc("/products/{id:[0-9]+}/translations/{locale}", a.deleteTranslationHandler).Methods("DELETE").Name("delete-translation")
+	r.HandleFunc("/categories/{category}/stock", a.categoryStockHandler).Methods("GET").Name("category-stock")
+	r.HandleFunc("/categories/{category}/price-stats", a.categoryPriceStatsHandler).Methods("GET").Name("category-price-stats")
+	r.HandleFunc("/products/{id:[0-9]+}/variants", a.listVariantsHandler).Methods("GET").Name("list-variants")
+	r.HandleFunc("/products/{id:[0-9]+}/variants", a.createVariantHandler).Methods("POST").Name("create-variant")
+	r.HandleFunc("/products/{id:[0-9]+}/variants/{variantId:[0-9]+}", a.getVariantHandler).Methods("GET").Name("get-variant")
+	r.HandleFunc("/products/{id:[0-9]+}/variants/{variantId:[0-9]+}", a.updateVariantHandler).Methods("PUT").Name("update-variant")
+	r.HandleFunc("/products/{id:[0-9]+}/variants/{variantId:[0-9]+}", a.deleteVariantHandler).Methods("DELETE").Name("delete-variant")
+	return r
+}
+
+// wrapRouter menambahkan lapisan di luar mux: gerbang startup, trailing slash, dan CORS
+func wrapRouter(r *mux.Router, trailingSlash string) http.Handler {
+	var handler http.Handler = startupGateMiddleware(r)
+	if trailingSlash == trailingSlashIgnore {
+		handler = trailingSlashMiddleware(handler)
+	}
+	handler = corsMiddleware(loadCORSConfig())(handler)
+	return handler
+}
+
+func (a *App) initDB() {
 	var err error
-	db, err = sql.Open("postgres", connStr)
+	connector, err := pq.NewConnector(a.cfg.DatabaseURL)
 	if err != nil {
 		log.Fatalf("Gagal membuka koneksi database: %v", err)
 	}
+	// Dibungkus agar jumlah query per request dapat dihitung
+	a.db = sql.OpenDB(countingConnector{connector})
+	a.configureDBPool()
 	for i := 0; i < 5; i++ {
-		err = db.Ping()
+		err = a.db.Ping()
 		if err == nil {
-			log.Println("Berhasil terhubung ke database.")
+			slog.Info("Berhasil terhubung ke database")
 			return
 		}
-		log.Printf("Gagal ping database, mencoba lagi dalam 2 detik... (%v)", err)
+		slog.Warn("Gagal ping database, mencoba lagi dalam 2 detik", "err", err)
 		time.Sleep(2 * time.Second)
 	}
 	log.Fatalf("Tidak dapat terhubung ke database setelah beberapa kali percobaan: %v", err)
 }
 
-func initRedis(redisURL string) {
-	rdb = redis.NewClient(&redis.Options{
-		Addr: redisURL,
+func (a *App) initRedis() {
+	a.rdb = redis.NewClient(&redis.Options{
+		Addr:     a.cfg.RedisURL,
+		Password: a.cfg.RedisPassword,
+		DB:       a.cfg.RedisDB,
 	})
-	if _, err := rdb.Ping(ctx).Result(); err != nil {
+	a.rdb.AddHook(cacheLatencyHook{})
+	if _, err := a.rdb.Ping(context.Background()).Result(); err != nil {
 		log.Fatalf("Tidak dapat terhubung ke Redis: %v", err)
 	}
-	log.Println("Berhasil terhubung ke Redis.")
+	slog.Info("Berhasil terhubung ke Redis")
 }
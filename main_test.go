@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// Query tulis dan transaksi dibatasi DB_QUERY_TIMEOUT, bukan hanya batas waktu route
+func TestWritesRespectQueryTimeout(t *testing.T) {
+	old := dbQueryTimeout
+	dbQueryTimeout = 20 * time.Millisecond
+	t.Cleanup(func() { dbQueryTimeout = old })
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		expect func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name: "soft delete", method: http.MethodDelete, path: "/products/1",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`UPDATE products SET deleted_at = now\(\)`).WillDelayFor(time.Second).
+					WillReturnRows(sqlmock.NewRows([]string{"category"}).AddRow("alat"))
+			},
+		},
+		{
+			name: "hapus varian", method: http.MethodDelete, path: "/products/1/variants/2",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`DELETE FROM product_variants`).WillDelayFor(time.Second).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "transaksi reserve", method: http.MethodPost, path: "/products/1/reserve", body: `{"quantity":1}`,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT stock, category, low_stock_threshold FROM products`).WillDelayFor(time.Second).
+					WillReturnRows(sqlmock.NewRows([]string{"stock", "category", "low_stock_threshold"}).AddRow(5, "alat", nil))
+				mock.ExpectRollback()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, mock, _ := newTestApp(t)
+			tt.expect(mock)
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			start := time.Now()
+			a.newRouter(trailingSlashIgnore, false).ServeHTTP(w, req)
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Fatalf("handler menunggu %v, batas query tidak dipakai", elapsed)
+			}
+			if w.Code != http.StatusInternalServerError {
+				t.Fatalf("status %d, ingin 500; body: %s", w.Code, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// metricsHandler mengekspos metrik dalam format teks Prometheus
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+	cacheLatency.writePrometheus(&b)
+	writeCacheWriteMetrics(&b)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(b.String()))
+}
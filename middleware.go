@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+// Konfigurasi header keamanan, dibaca dari env saat startup
+type securityHeadersConfig struct {
+	Enabled            bool
+	HSTS               bool // opt-in, hanya relevan jika diakses lewat HTTPS
+	HSTSMaxAge         int
+	HSTSSubdomains     bool
+	ContentTypeNosniff bool
+	FrameOptions       string
+	CSP                string
+}
+
+func loadSecurityHeadersConfig() securityHeadersConfig {
+	return securityHeadersConfig{
+		Enabled:            getEnvBool("SECURITY_HEADERS", true),
+		HSTS:               getEnvBool("HSTS_ENABLED", false),
+		HSTSMaxAge:         getEnvInt("HSTS_MAX_AGE", 31536000),
+		HSTSSubdomains:     getEnvBool("HSTS_INCLUDE_SUBDOMAINS", false),
+		ContentTypeNosniff: getEnvBool("X_CONTENT_TYPE_NOSNIFF", true),
+		FrameOptions:       getEnv("X_FRAME_OPTIONS", "DENY"),
+		CSP:                getEnv("CONTENT_SECURITY_POLICY", "default-src 'none'; frame-ancestors 'none'"),
+	}
+}
+
+// securityHeadersMiddleware memasang header keamanan pada setiap respons.
+// Setel nilai env (mis. X_FRAME_OPTIONS) ke "off" untuk menonaktifkan satu header.
+func securityHeadersMiddleware(cfg securityHeadersConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !cfg.Enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			if cfg.HSTS {
+				v := fmt.Sprintf("max-age=%d", cfg.HSTSMaxAge)
+				if cfg.HSTSSubdomains {
+					v += "; includeSubDomains"
+				}
+				h.Set("Strict-Transport-Security", v)
+			}
+			if cfg.ContentTypeNosniff {
+				h.Set("X-Content-Type-Options", "nosniff")
+			}
+			if cfg.FrameOptions != "" && cfg.FrameOptions != "off" {
+				h.Set("X-Frame-Options", cfg.FrameOptions)
+			}
+			if cfg.CSP != "" && cfg.CSP != "off" {
+				h.Set("Content-Security-Policy", cfg.CSP)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Perilaku untuk path dengan garis miring di akhir (mis. /products/), diatur lewat env TRAILING_SLASH:
+//   - "ignore" (default): /products/ diperlakukan sama dengan /products. Dipilih sebagai default
+//     karena redirect 301 tidak aman untuk POST/PUT (banyak klien mengubahnya menjadi GET).
+//   - "redirect": redirect 301 ke path tanpa garis miring (StrictSlash milik mux).
+//   - "strict": path dengan garis miring dianggap berbeda dan menghasilkan 404.
+const (
+	trailingSlashIgnore   = "ignore"
+	trailingSlashRedirect = "redirect"
+	trailingSlashStrict   = "strict"
+)
+
+// trailingSlashMiddleware harus membungkus router (bukan lewat r.Use), karena
+// middleware mux baru dijalankan setelah route cocok.
+func trailingSlashMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p := r.URL.Path; len(p) > 1 && strings.HasSuffix(p, "/") {
+			r.URL.Path = strings.TrimRight(p, "/")
+			if r.URL.Path == "" {
+				r.URL.Path = "/"
+			}
+			if r.URL.RawPath != "" {
+				r.URL.RawPath = strings.TrimRight(r.URL.RawPath, "/")
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireContentLengthMiddleware (opt-in lewat REQUIRE_CONTENT_LENGTH) menolak request tulis
+// tanpa Content-Length, termasuk body chunked, dengan 411 Length Required.
+func requireContentLengthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			chunked := len(r.TransferEncoding) > 0
+			if chunked || r.ContentLength < 0 {
+				writeJSONError(w, http.StatusLengthRequired, "Header Content-Length wajib disertakan")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recoverMiddleware menangkap panic dari handler agar satu request yang bermasalah tidak
+// menjatuhkan proses. Panic selalu dicatat beserta stack trace lalu dijawab 500.
+// http.ErrAbortHandler diteruskan karena memang dipakai untuk membatalkan respons.
+func recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			slog.ErrorContext(r.Context(), "Panic saat menangani request",
+				"method", r.Method, "path", r.URL.Path, "panic", fmt.Sprint(rec), "stack", string(debug.Stack()))
+			// Bila handler sudah mulai menulis respons, status 500 tidak lagi dapat dikirim
+			writeJSONError(w, http.StatusInternalServerError, "Terjadi kesalahan internal")
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
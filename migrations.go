@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"io/fs"
+	"log/slog"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// File migrasi ditanam ke dalam binary, sehingga binary selalu tahu versi skema yang diharapkannya
+//
+//go:embed db/migration/*.sql
+var migrationFS embed.FS
+
+const migrationDir = "db/migration"
+
+// newMigrate membuat instance migrate di atas satu koneksi dari pool.
+// Close() hanya menutup koneksi tersebut, bukan *sql.DB milik aplikasi.
+func (a *App) newMigrate(c context.Context) (*migrate.Migrate, error) {
+	src, err := iofs.New(migrationFS, migrationDir)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := a.db.Conn(c)
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+	driver, err := postgres.WithConnection(c, conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		src.Close()
+		return nil, err
+	}
+	return migrate.NewWithInstance("iofs", src, "postgres", driver)
+}
+
+// expectedMigrationVersion mengembalikan versi migrasi tertinggi yang ditanam di binary
+func expectedMigrationVersion() (uint, error) {
+	src, err := iofs.New(migrationFS, migrationDir)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
+
+// pqUndefinedTable: schema_migrations belum dibuat karena migrasi belum pernah dijalankan
+const pqUndefinedTable = "42P01"
+
+// currentMigrationVersion membaca versi skema yang tercatat di database.
+// Versi 0 berarti belum ada migrasi yang dijalankan.
+//
+// Tabel dibaca langsung, bukan lewat newMigrate: instance migrate mengambil pg_advisory_lock
+// dengan context.Background(), sehingga selama migrasi berjalan setiap probe /readyz akan
+// menunggu tanpa batas waktu sambil memegang satu koneksi pool.
+func (a *App) currentMigrationVersion(c context.Context) (version uint, dirty bool, err error) {
+	var v int64
+	err = a.db.QueryRowContext(c, `SELECT version, dirty FROM `+postgres.DefaultMigrationsTable+` LIMIT 1`).Scan(&v, &dirty)
+	if errors.Is(err, sql.ErrNoRows) || isPQError(err, pqUndefinedTable) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return uint(v), dirty, nil
+}
+
+// runMigrations menerapkan semua migrasi yang belum dijalankan (mode -migrate).
+// migrate.ErrNoChange tidak dianggap gagal, tetapi dicatat secara eksplisit.
+func (a *App) runMigrations(c context.Context) error {
+	m, err := a.newMigrate(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil {
+		if errors.Is(err, migrate.ErrNoChange) {
+			slog.InfoContext(c, "Tidak ada migrasi yang perlu dijalankan")
+			return nil
+		}
+		return err
+	}
+	version, _, err := m.Version()
+	if err != nil {
+		return err
+	}
+	slog.InfoContext(c, "Migrasi selesai", "version", version)
+	return nil
+}
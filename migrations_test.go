@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+func TestCurrentMigrationVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		rows        *sqlmock.Rows
+		err         error
+		wantVersion uint
+		wantDirty   bool
+		wantErr     bool
+	}{
+		{name: "bersih", rows: sqlmock.NewRows([]string{"version", "dirty"}).AddRow(17, false), wantVersion: 17},
+		{name: "dirty", rows: sqlmock.NewRows([]string{"version", "dirty"}).AddRow(16, true), wantVersion: 16, wantDirty: true},
+		{name: "tabel kosong", rows: sqlmock.NewRows([]string{"version", "dirty"})},
+		{name: "tabel belum ada", err: &pq.Error{Code: pqUndefinedTable}},
+		{name: "database error", err: errors.New("connection refused"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, mock, _ := newTestApp(t)
+			q := mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations LIMIT 1`)
+			if tt.err != nil {
+				q.WillReturnError(tt.err)
+			} else {
+				q.WillReturnRows(tt.rows)
+			}
+			version, dirty, err := a.currentMigrationVersion(context.Background())
+			if (err != nil) != tt.wantErr || version != tt.wantVersion || dirty != tt.wantDirty {
+				t.Fatalf("= (%d, %v, %v), ingin (%d, %v, err=%v)", version, dirty, err, tt.wantVersion, tt.wantDirty, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Money adalah nilai uang dalam sen (dua angka desimal, sama dengan kolom NUMERIC(12, 2)),
+// sehingga harga tidak terkena pembulatan float. Di JSON tetap berupa angka seperti 19.99.
+type Money int64
+
+var errMoneyFormat = errors.New("nilai uang harus berupa angka dengan maksimal 2 angka desimal")
+
+// parseMoney membaca "19.99", "-5", atau "20.5" tanpa melewati float. Lebih dari dua angka
+// desimal ditolak alih-alih dibulatkan diam-diam. Notasi eksponen (1e3) tidak didukung.
+func parseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	whole, frac, _ := strings.Cut(s, ".")
+	frac = strings.TrimRight(frac, "0") // 19.990 tetap tepat 19.99
+	if whole == "" || len(frac) > 2 || strings.ContainsAny(whole+frac, "+-eE") {
+		return 0, errMoneyFormat
+	}
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > (1<<63-1)/100-1 {
+		return 0, errMoneyFormat
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+	cents, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return 0, errMoneyFormat
+	}
+	m := Money(units*100 + cents)
+	if neg {
+		m = -m
+	}
+	return m, nil
+}
+
+// String menulis nilai dengan angka nol di belakang dipangkas: 19.99, 20.5, 20
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	s := fmt.Sprintf("%s%d.%02d", sign, v/100, v%100)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// Float64 hanya untuk perhitungan turunan (persentase margin, protobuf), bukan untuk disimpan
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON menerima angka JSON, atau string berisi angka dari klien lama
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	v, err := parseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Scan membaca NUMERIC dari Postgres, yang dikirim lib/pq sebagai teks
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return m.scanText(string(v))
+	case string:
+		return m.scanText(v)
+	case int64:
+		*m = Money(v * 100)
+		return nil
+	case float64:
+		return m.scanText(strconv.FormatFloat(v, 'f', 2, 64))
+	}
+	return fmt.Errorf("tipe %T tidak dapat dipindai sebagai Money", src)
+}
+
+func (m *Money) scanText(s string) error {
+	v, err := parseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Value mengirim nilai sebagai teks desimal agar Postgres menerimanya apa adanya
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
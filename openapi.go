@@ -0,0 +1,414 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Dokumen OpenAPI 3 di /openapi.json dan Swagger UI di /docs.
+//
+// Path ditulis tangan di openAPIPaths, sedangkan skema body dibangkitkan dari struct Go
+// (tag json) lewat openAPISchema, sehingga field baru pada Product otomatis ikut terdokumentasi.
+// Saat menambah route publik, tambahkan juga entrinya di openAPIPaths.
+
+const openAPIVersion = "3.0.3"
+
+// Skema komponen yang dibangkitkan dari struct; nama dipakai di $ref
+var openAPIComponentTypes = map[string]reflect.Type{
+	"Product":      reflect.TypeOf(Product{}),
+	"AdminProduct": reflect.TypeOf(adminProduct{}),
+}
+
+var (
+	openAPIOnce sync.Once
+	openAPIDoc  []byte
+	openAPIErr  error // hasil gagal juga disimpan agar setiap request mendapat jawaban yang sama
+)
+
+func openAPIRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+// openAPISchema menerjemahkan tipe Go menjadi JSON Schema versi OpenAPI 3.0. Tipe dengan
+// MarshalJSON sendiri dipetakan manual karena bentuk JSON-nya tidak tampak dari struct.
+func openAPISchema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case reflect.TypeOf(Money(0)):
+		return map[string]interface{}{"type": "number", "format": "decimal", "description": "Nilai uang dengan maksimal 2 angka desimal"}
+	case reflect.TypeOf(JSONTime{}):
+		switch jsonTimeFormat {
+		case timeFormatUnix:
+			return map[string]interface{}{"type": "integer", "format": "int64", "description": "Detik sejak epoch (TIME_FORMAT=unix)"}
+		case timeFormatUnixMilli:
+			return map[string]interface{}{"type": "integer", "format": "int64", "description": "Milidetik sejak epoch (TIME_FORMAT=unixmilli)"}
+		}
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case reflect.TypeOf(SortedMap{}):
+		return map[string]interface{}{"type": "object", "additionalProperties": true}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := openAPISchema(t.Elem())
+		s["nullable"] = true
+		return s
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": openAPISchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": openAPISchema(t.Elem())}
+	case reflect.Struct:
+		props := map[string]interface{}{}
+		var required []string
+		addOpenAPIFields(t, props, &required)
+		s := map[string]interface{}{"type": "object", "properties": props}
+		if len(required) > 0 {
+			s["required"] = required
+		}
+		return s
+	}
+	return map[string]interface{}{}
+}
+
+// addOpenAPIFields mengikuti aturan encoding/json: field embedded diratakan, tag "-" dilewati,
+// dan field tanpa omitempty dianggap selalu ada (required)
+func addOpenAPIFields(t reflect.Type, props map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			addOpenAPIFields(f.Type, props, required)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = openAPISchema(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func openAPIJSON(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+func openAPIResponse(description string, schema interface{}) map[string]interface{} {
+	r := map[string]interface{}{"description": description}
+	if schema != nil {
+		r["content"] = openAPIJSON(schema)
+	}
+	return r
+}
+
+func openAPIError(description string) map[string]interface{} {
+	return openAPIResponse(description, openAPIRef("Error"))
+}
+
+var openAPIIDParam = map[string]interface{}{
+	"name": "id", "in": "path", "required": true, "schema": map[string]interface{}{"type": "integer"},
+}
+
+func openAPIQuery(name, description string, schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"name": name, "in": "query", "description": description, "schema": schema}
+}
+
+// openAPIListOperation mendokumentasikan handleGetProducts, yang dipasang di dua rute daftar.
+// Filter ?attr.<nama>=<nilai> tidak dapat dinyatakan sebagai parameter OpenAPI biasa,
+// jadi hanya disebut di deskripsi.
+func openAPIListOperation(operationID, summary string, productList map[string]interface{}) map[string]interface{} {
+	str := map[string]interface{}{"type": "string"}
+	integer := map[string]interface{}{"type": "integer"}
+	boolean := map[string]interface{}{"type": "boolean"}
+	return map[string]interface{}{
+		"summary":     summary,
+		"operationId": operationID,
+		"description": "Filter atribut memakai ?attr.<nama>=<nilai> (mis. ?attr.material=kayu), dicocokkan sebagai string.",
+		"parameters": []interface{}{
+			openAPIQuery("limit", "Ukuran halaman (default 50, maksimal 200)", integer),
+			openAPIQuery("page", "Nomor halaman mulai dari 1", integer),
+			openAPIQuery("offset", "Offset eksplisit; mengalahkan page", integer),
+			openAPIQuery("q", "Pencarian teks penuh", str),
+			openAPIQuery("name", "Potongan nama (tidak membedakan huruf besar/kecil)", str),
+			openAPIQuery("min_price", "Harga minimum (inklusif)", map[string]interface{}{"type": "number"}),
+			openAPIQuery("max_price", "Harga maksimum (inklusif)", map[string]interface{}{"type": "number"}),
+			openAPIQuery("updated_after", "RFC3339", map[string]interface{}{"type": "string", "format": "date-time"}),
+			openAPIQuery("updated_before", "RFC3339", map[string]interface{}{"type": "string", "format": "date-time"}),
+			openAPIQuery("sort", "Kolom urutan", map[string]interface{}{"type": "string", "enum": []string{"id", "name", "price", "stock", "created_at", "updated_at"}}),
+			openAPIQuery("order", "Arah urutan", map[string]interface{}{"type": "string", "enum": []string{"asc", "desc"}}),
+			openAPIQuery("include_deleted", "Ikut tampilkan produk yang sudah dihapus (hanya admin)", boolean),
+			openAPIQuery("best_effort", "Setelah tenggat lunak, kirim baris yang sudah terbaca sebagai hasil parsial", boolean),
+			openAPIQuery("verify", "Cocokkan cache dengan database sebelum menjawab", boolean),
+			openAPIQuery("envelope", "Bungkus respons dalam {apiVersion, data, meta}", boolean),
+			map[string]interface{}{"name": "If-None-Match", "in": "header", "schema": str, "description": "ETag daftar dari respons sebelumnya"},
+		},
+		"responses": map[string]interface{}{
+			"200": openAPIResponse("Daftar produk; total ada di header X-Total-Count", productList),
+			"304": openAPIResponse("Tidak berubah sejak ETag di If-None-Match", nil),
+			"400": openAPIError("Parameter tidak valid"),
+		},
+	}
+}
+
+func openAPIPaths() map[string]interface{} {
+	str := map[string]interface{}{"type": "string"}
+	integer := map[string]interface{}{"type": "integer"}
+	productList := map[string]interface{}{"type": "array", "items": openAPIRef("Product")}
+	return map[string]interface{}{
+		"/products-standard": map[string]interface{}{
+			"get": openAPIListOperation("listProducts", "Daftar produk terpaginasi (serialisasi encoding/json)", productList),
+		},
+		"/products-iterator": map[string]interface{}{
+			"get": openAPIListOperation("listProductsIterator", "Daftar produk terpaginasi (serialisasi jsoniter); respons identik dengan /products-standard", productList),
+		},
+		"/products": map[string]interface{}{
+			"post": map[string]interface{}{
+				"summary":     "Buat produk",
+				"operationId": "createProduct",
+				"parameters": []interface{}{map[string]interface{}{
+					"name": "Idempotency-Key", "in": "header", "schema": str,
+					"description": "Retry dengan kunci yang sama mengembalikan respons pertama",
+				}},
+				"requestBody": map[string]interface{}{"required": true, "content": openAPIJSON(openAPIRef("NewProduct"))},
+				"responses": map[string]interface{}{
+					"201": openAPIResponse("Produk dibuat; header Location menunjuk ke resource baru", openAPIRef("Product")),
+					"400": openAPIError("Body tidak valid"),
+					"409": openAPIError("SKU sudah dipakai"),
+					"413": openAPIError("Body terlalu besar"),
+					"422": openAPIError("Harga di luar batas"),
+				},
+			},
+		},
+		"/products/export": map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":     "Unduh seluruh katalog sebagai CSV atau NDJSON (di-stream, mendukung filter daftar produk)",
+				"operationId": "exportProducts",
+				"parameters": []interface{}{
+					openAPIQuery("format", "Format berkas (default csv)", map[string]interface{}{"type": "string", "enum": []string{"csv", "json"}}),
+				},
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "Berkas products.csv atau products.jsonl (Content-Disposition: attachment)",
+						"content": map[string]interface{}{
+							"text/csv":             map[string]interface{}{"schema": str},
+							"application/x-ndjson": map[string]interface{}{"schema": openAPIRef("Product")},
+						},
+					},
+					"400": openAPIError("Format atau filter tidak valid"),
+				},
+			},
+		},
+		"/products/{id}": map[string]interface{}{
+			"parameters": []interface{}{openAPIIDParam},
+			"get": map[string]interface{}{
+				"summary":     "Detail produk",
+				"operationId": "getProduct",
+				"responses": map[string]interface{}{
+					"200": openAPIResponse("Produk (AdminProduct untuk kunci API admin)", openAPIRef("Product")),
+					"404": openAPIError("Produk tidak ditemukan"),
+				},
+			},
+			"put": map[string]interface{}{
+				"summary":     "Perbarui nama, harga, dan stok produk",
+				"operationId": "updateProduct",
+				"parameters": []interface{}{map[string]interface{}{
+					"name": "If-Match", "in": "header", "schema": str, "description": "ETag produk untuk optimistic locking",
+				}},
+				"requestBody": map[string]interface{}{"required": true, "content": openAPIJSON(openAPIRef("Product"))},
+				"responses": map[string]interface{}{
+					"200": openAPIResponse("Produk setelah diperbarui", openAPIRef("Product")),
+					"400": openAPIError("Body tidak valid"),
+					"404": openAPIError("Produk tidak ditemukan"),
+					"409": openAPIError("Versi produk sudah berubah"),
+					"412": openAPIError("If-Match tidak cocok"),
+				},
+			},
+			"delete": map[string]interface{}{
+				"summary":     "Hapus produk (soft delete)",
+				"operationId": "deleteProduct",
+				"responses": map[string]interface{}{
+					"204": openAPIResponse("Produk dihapus", nil),
+					"404": openAPIError("Produk tidak ditemukan"),
+				},
+			},
+		},
+		"/products/{id}/stock": map[string]interface{}{
+			"parameters": []interface{}{openAPIIDParam},
+			"put": map[string]interface{}{
+				"summary":     "Setel stok absolut atau ubah secara relatif",
+				"operationId": "updateStock",
+				"requestBody": map[string]interface{}{"required": true, "content": openAPIJSON(openAPIRef("StockUpdate"))},
+				"responses": map[string]interface{}{
+					"200": openAPIResponse("Stok diperbarui", nil),
+					"400": openAPIError("Body tidak valid"),
+					"404": openAPIError("Produk tidak ditemukan"),
+					"409": openAPIError("Stok tidak mencukupi atau versi berubah"),
+				},
+			},
+		},
+		"/products/{id}/reserve": map[string]interface{}{
+			"parameters": []interface{}{openAPIIDParam},
+			"post": map[string]interface{}{
+				"summary":     "Kurangi stok secara atomik untuk checkout (mendukung Idempotency-Key)",
+				"operationId": "reserveStock",
+				"requestBody": map[string]interface{}{"required": true, "content": openAPIJSON(map[string]interface{}{
+					"type":       "object",
+					"required":   []string{"quantity"},
+					"properties": map[string]interface{}{"quantity": map[string]interface{}{"type": "integer", "minimum": 1}},
+				})},
+				"responses": map[string]interface{}{
+					"200": openAPIResponse("Stok direservasi", openAPISchema(reflect.TypeOf(reserveResult{}))),
+					"400": openAPIError("Body tidak valid"),
+					"404": openAPIError("Produk tidak ditemukan"),
+					"409": openAPIResponse("Stok tidak mencukupi; field available berisi stok dikurangi reservasi aktif", map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"error":     map[string]interface{}{"type": "string"},
+							"available": map[string]interface{}{"type": "integer"},
+						},
+					}),
+				},
+			},
+		},
+		"/products/{id}/history": map[string]interface{}{
+			"parameters": []interface{}{openAPIIDParam},
+			"get": map[string]interface{}{
+				"summary":     "Riwayat perubahan nama, harga, dan stok produk (terbaru lebih dulu)",
+				"operationId": "getProductHistory",
+				"parameters": []interface{}{
+					openAPIQuery("limit", "Jumlah entri (default 100, maksimal 500)", integer),
+				},
+				"responses": map[string]interface{}{
+					"200": openAPIResponse("Daftar perubahan", map[string]interface{}{"type": "array", "items": openAPIRef("AuditEntry")}),
+					"404": openAPIError("Produk tidak ditemukan"),
+				},
+			},
+		},
+		"/products/batch": map[string]interface{}{
+			"post": map[string]interface{}{
+				"summary":     "Buat banyak produk dalam satu transaksi",
+				"operationId": "createProductsBatch",
+				"requestBody": map[string]interface{}{"required": true, "content": openAPIJSON(map[string]interface{}{"type": "array", "items": openAPIRef("NewProduct")})},
+				"responses": map[string]interface{}{
+					"201": openAPIResponse("Produk dibuat", productList),
+					"400": openAPIError("Salah satu item tidak valid (lihat index)"),
+				},
+			},
+		},
+		"/livez": map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":   "Liveness probe",
+				"responses": map[string]interface{}{"200": openAPIResponse("Proses hidup", nil)},
+			},
+		},
+		"/readyz": map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary": "Readiness probe",
+				"responses": map[string]interface{}{
+					"200": openAPIResponse("Siap melayani trafik", nil),
+					"503": openAPIResponse("Startup belum selesai atau dependensi tidak dapat dijangkau", nil),
+				},
+			},
+		},
+	}
+}
+
+func buildOpenAPIDoc() map[string]interface{} {
+	schemas := map[string]interface{}{}
+	for name, t := range openAPIComponentTypes {
+		schemas[name] = openAPISchema(t)
+	}
+	newProduct := openAPISchema(reflect.TypeOf(newProductInput{}))
+	newProduct["required"] = []string{"name", "price"}
+	schemas["NewProduct"] = newProduct
+	schemas["StockUpdate"] = map[string]interface{}{
+		"type":        "object",
+		"description": "Isi salah satu dari stock (absolut) atau delta (relatif)",
+		"properties": map[string]interface{}{
+			"stock":   map[string]interface{}{"type": "integer"},
+			"delta":   map[string]interface{}{"type": "integer"},
+			"version": map[string]interface{}{"type": "integer"},
+		},
+	}
+	schemas["AuditEntry"] = openAPISchema(reflect.TypeOf(auditEntry{}))
+	schemas["Error"] = map[string]interface{}{
+		"type":       "object",
+		"required":   []string{"error"},
+		"properties": map[string]interface{}{"error": map[string]interface{}{"type": "string"}},
+	}
+	return map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":   "ping-pong product API",
+			"version": APIVersion,
+		},
+		"paths":      openAPIPaths(),
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+}
+
+// openAPIHandler menyajikan dokumen OpenAPI; dibangun sekali karena bergantung pada
+// konfigurasi startup (mis. TIME_FORMAT) yang tidak berubah saat berjalan
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	openAPIOnce.Do(func() {
+		openAPIDoc, openAPIErr = jsoni.Marshal(buildOpenAPIDoc())
+		if openAPIErr != nil {
+			slog.Error("Gagal membangun dokumen OpenAPI", "err", openAPIErr)
+		}
+	})
+	if openAPIErr != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal membangun dokumen OpenAPI")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIDoc)
+}
+
+const swaggerUIVersion = "5.17.14"
+
+var swaggerUIPage = `<!DOCTYPE html>
+<html lang="id">
+<head>
+<meta charset="utf-8">
+<title>ping-pong API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui-bundle.js"></script>
+<script src="/docs/init.js"></script>
+</body>
+</html>
+`
+
+const swaggerUIInit = `window.ui = SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});
+`
+
+// docsHandler menyajikan Swagger UI dari CDN. CSP bawaan (default-src 'none') dilonggarkan
+// khusus halaman ini agar skrip dan stylesheet dari unpkg boleh dimuat.
+func docsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Security-Policy",
+		"default-src 'none'; script-src 'self' https://unpkg.com; style-src https://unpkg.com; img-src 'self' data: https://unpkg.com; connect-src 'self'; frame-ancestors 'none'")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
+
+func docsInitHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/javascript")
+	w.Write([]byte(swaggerUIInit))
+}
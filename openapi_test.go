@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestOpenAPIHandler(t *testing.T) {
+	t.Cleanup(func() { openAPIOnce, openAPIDoc, openAPIErr = sync.Once{}, nil, nil })
+
+	w := httptest.NewRecorder()
+	openAPIHandler(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d; body: %s", w.Code, w.Body)
+	}
+	var doc struct {
+		Paths map[string]map[string]interface{} `json:"paths"`
+	}
+	if err := jsoni.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := doc.Paths["/products-standard"]["get"]; !ok {
+		t.Fatal("GET /products-standard tidak terdokumentasi")
+	}
+	if _, ok := doc.Paths["/products"]["get"]; ok {
+		t.Fatal("GET /products terdokumentasi padahal route-nya tidak ada")
+	}
+}
+
+// Kegagalan membangun dokumen dijawab 500 pada setiap request, bukan panic
+func TestOpenAPIHandlerBuildError(t *testing.T) {
+	openAPIOnce, openAPIDoc, openAPIErr = sync.Once{}, nil, nil
+	openAPIOnce.Do(func() { openAPIErr = errors.New("gagal") })
+	t.Cleanup(func() { openAPIOnce, openAPIDoc, openAPIErr = sync.Once{}, nil, nil })
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		openAPIHandler(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("request ke-%d: status %d, ingin 500", i+1, w.Code)
+		}
+	}
+}
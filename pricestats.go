@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func categoryPriceStatsCacheKey(category string) string {
+	return "category:" + category + ":price-stats"
+}
+
+// Statistik bernilai nil bila kategori tidak memiliki produk
+type priceStats struct {
+	Category string   `json:"category"`
+	Count    int      `json:"count"`
+	Min      *float64 `json:"min"`
+	Max      *float64 `json:"max"`
+	Average  *float64 `json:"average"`
+	Median   *float64 `json:"median"`
+	StdDev   *float64 `json:"stddev"`
+}
+
+// categoryPriceStatsHandler menghitung statistik harga satu kategori dalam satu query agregat.
+// Simpangan baku memakai stddev_pop sehingga kategori dengan satu produk bernilai 0.
+func (a *App) categoryPriceStatsHandler(w http.ResponseWriter, r *http.Request) {
+	category := mux.Vars(r)["category"]
+	cacheKey := categoryPriceStatsCacheKey(category)
+
+	if cached, err := a.rdb.Get(r.Context(), cacheKey).Result(); err == nil {
+		slog.DebugContext(r.Context(), "CACHE HIT: Mengambil dari Redis", "key", cacheKey)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(cached))
+		return
+	}
+
+	slog.DebugContext(r.Context(), "CACHE MISS: Mengambil dari PostgreSQL", "key", cacheKey)
+	sqlStatement := `SELECT COUNT(*), MIN(price), MAX(price), AVG(price),
+		percentile_cont(0.5) WITHIN GROUP (ORDER BY price), stddev_pop(price)
+		FROM products WHERE category = $1 AND deleted_at IS NULL`
+	stats := priceStats{Category: category}
+	var lowest, highest, avg, median, stddev sql.NullFloat64
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	err := a.db.QueryRowContext(qc, sqlStatement, category).
+		Scan(&stats.Count, &lowest, &highest, &avg, &median, &stddev)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal menghitung statistik harga")
+		return
+	}
+	stats.Min = nullFloat(lowest)
+	stats.Max = nullFloat(highest)
+	stats.Average = nullFloat(avg)
+	stats.Median = nullFloat(median)
+	stats.StdDev = nullFloat(stddev)
+
+	jsonData, err := jsoni.Marshal(stats)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mem-format data untuk cache")
+		return
+	}
+	if err := a.rdb.Set(r.Context(), cacheKey, jsonData, cacheTTL).Err(); err != nil {
+		slog.ErrorContext(r.Context(), "Gagal menyimpan ke Redis", "err", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonData)
+}
+
+func nullFloat(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	f := round2(v.Float64)
+	return &f
+}
+
+// invalidateCategoryPriceStats dipanggil setiap kali harga atau keanggotaan produk dalam kategori berubah
+func (a *App) invalidateCategoryPriceStats(c context.Context, category string) {
+	if err := a.rdb.Del(detachedContext(c), categoryPriceStatsCacheKey(category)).Err(); err != nil {
+		slog.Error("Gagal menghapus cache Redis", "err", err)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Cache detail produk disimpan di product:{id}, kunci yang sama dengan sentinel 404.
+// Yang disimpan adalah rekaman internal (termasuk cost dan terjemahan), sehingga satu entri
+// melayani semua representasi: publik, admin, lokal, dan protobuf.
+type productCacheRecord struct {
+	Product
+	Cost         *Money                        `json:"cost,omitempty"`
+	Translations map[string]productTranslation `json:"translations,omitempty"`
+	// updatedAt presisi penuh; JSON publik bisa dibulatkan ke detik (TIME_FORMAT) dan ETag bergantung padanya
+	UpdatedAtMicro int64 `json:"updatedAtMicro"`
+}
+
+// cachedProduct membaca detail produk dari cache. notFound bernilai true bila yang
+// tersimpan adalah sentinel 404.
+func (a *App) cachedProduct(c context.Context, id int) (p Product, hit, notFound bool) {
+	cached, err := a.rdb.Get(c, productCacheKey(id)).Result()
+	if err != nil {
+		return p, false, false
+	}
+	if cached == cacheNilSentinel {
+		return p, true, true
+	}
+	var rec productCacheRecord
+	if err := jsoni.Unmarshal([]byte(cached), &rec); err != nil {
+		slog.Warn("Cache produk rusak, diabaikan", "product_id", id, "err", err)
+		return p, false, false
+	}
+	p = rec.Product
+	p.Cost = rec.Cost
+	p.Translations = rec.Translations
+	p.UpdatedAt.Time = time.UnixMicro(rec.UpdatedAtMicro).UTC()
+	return p, true, false
+}
+
+// cacheProduct menyimpan detail produk dengan TTL produk (override per produk bila ada)
+func (a *App) cacheProduct(c context.Context, p Product) {
+	data, err := jsoni.Marshal(productCacheRecord{
+		Product: p, Cost: p.Cost, Translations: p.Translations, UpdatedAtMicro: p.UpdatedAt.UnixMicro(),
+	})
+	if err != nil {
+		slog.Error("Gagal mem-format produk untuk cache", "product_id", p.ID, "err", err)
+		return
+	}
+	if err := a.rdb.Set(c, productCacheKey(p.ID), data, productCacheTTL(p)).Err(); err != nil {
+		slog.Error("Gagal menyimpan ke Redis", "err", err)
+	}
+}
+
+// invalidateProductCache menghapus cache detail dan stok produk; dipanggil setiap kali produk berubah
+func (a *App) invalidateProductCache(c context.Context, ids ...int) {
+	if len(ids) == 0 {
+		return
+	}
+	keys := make([]string, 0, 2*len(ids))
+	for _, id := range ids {
+		keys = append(keys, productCacheKey(id), stockLevelCacheKey(id))
+	}
+	if err := a.rdb.Del(detachedContext(c), keys...).Err(); err != nil {
+		slog.Error("Gagal menghapus cache Redis", "err", err)
+	}
+}
+
+// invalidateProductCaches adalah satu-satunya jalur invalidasi setelah produk berubah:
+// detail dan stok produk, semua varian cache daftar (SCAN "products:*", lihat
+// invalidateProductListCaches), serta agregat stok dan harga untuk kategori yang disebut.
+// Kategori boleh dikosongkan bila perubahan tidak menyentuh stok maupun harga.
+func (a *App) invalidateProductCaches(c context.Context, id int, categories ...string) {
+	a.invalidateManyProductCaches(c, []int{id}, categories...)
+}
+
+// invalidateManyProductCaches sama dengan invalidateProductCaches untuk banyak produk
+// sekaligus, sehingga cache daftar hanya dipindai sekali per penulisan massal.
+func (a *App) invalidateManyProductCaches(c context.Context, ids []int, categories ...string) {
+	a.invalidateProductCache(c, ids...)
+	a.invalidateProductListCaches(c)
+	seen := map[string]bool{}
+	for _, category := range categories {
+		if seen[category] {
+			continue
+		}
+		seen[category] = true
+		a.invalidateCategoryStock(c, category)
+		a.invalidateCategoryPriceStats(c, category)
+	}
+}
+
+// Produk populer dihitung dari jumlah GET /products/{id}. Kuncinya sengaja di luar
+// "products:*" agar tidak ikut terhapus saat cache daftar diinvalidasi.
+const productPopularityKey = "popularity:products"
+
+func (a *App) recordProductView(c context.Context, id int) {
+	if err := a.rdb.ZIncrBy(c, productPopularityKey, 1, strconv.Itoa(id)).Err(); err != nil {
+		slog.Error("Gagal mencatat popularitas produk", "product_id", id, "err", err)
+	}
+}
+
+// warmTopProducts mengisi cache detail untuk n produk terpopuler (WARM_TOP_N) saat startup,
+// agar halaman produk terpanas langsung cepat setelah deploy.
+func (a *App) warmTopProducts(c context.Context, n int) {
+	start := time.Now()
+	members, err := a.rdb.ZRevRange(c, productPopularityKey, 0, int64(n-1)).Result()
+	if err != nil {
+		slog.ErrorContext(c, "Gagal membaca produk populer untuk pemanasan cache", "err", err)
+		return
+	}
+	ids := make([]int, 0, len(members))
+	for _, m := range members {
+		if id, err := strconv.Atoi(m); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	products, err := a.queryProducts(c, `SELECT `+productColumns+` FROM products p WHERE p.id = ANY($1) AND p.deleted_at IS NULL`, pq.Array(ids))
+	if err != nil {
+		slog.ErrorContext(c, "Gagal mengambil produk populer untuk pemanasan cache", "err", err)
+		return
+	}
+	for _, p := range products {
+		a.cacheProduct(c, p)
+	}
+	slog.InfoContext(c, "Pemanasan cache selesai", "products", len(products), "duration", time.Since(start))
+}
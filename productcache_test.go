@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// Bacaan cache mengikuti pembatalan request, sedangkan invalidasi setelah commit tetap
+// berjalan walau klien sudah memutus koneksi
+func TestProductCacheContext(t *testing.T) {
+	a, _, mr := newTestApp(t)
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	a.cacheProduct(context.Background(), Product{ID: 7, Name: "palu", Category: "alat"})
+	if _, hit, _ := a.cachedProduct(context.Background(), 7); !hit {
+		t.Fatal("produk tidak ter-cache")
+	}
+	if _, hit, _ := a.cachedProduct(cancelled, 7); hit {
+		t.Fatal("bacaan cache mengabaikan context yang dibatalkan")
+	}
+
+	mr.Set("products:page", "[]")
+	mr.Set(categoryStockCacheKey("alat"), "{}")
+	a.invalidateProductCaches(cancelled, 7, "alat")
+	for _, key := range []string{productCacheKey(7), "products:page", categoryStockCacheKey("alat")} {
+		if mr.Exists(key) {
+			t.Errorf("%s tidak terhapus oleh invalidasi dengan context yang dibatalkan", key)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"ping-pong/productpb"
+)
+
+// Respons biner untuk konsumen internal; skema ada di proto/product.proto.
+// JSON tetap default, protobuf hanya dikirim bila diminta lewat Accept.
+const protobufMediaType = "application/x-protobuf"
+
+// Deterministik agar byte cache (dan verifikasi cache) stabil meski ada map di attributes
+var protobufMarshal = proto.MarshalOptions{Deterministic: true}
+
+func wantsProtobuf(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, protobufMediaType) || strings.Contains(accept, "application/protobuf")
+}
+
+// productContentType mengembalikan media type respons produk sesuai negosiasi Accept
+func productContentType(r *http.Request) string {
+	if wantsProtobuf(r) {
+		return protobufMediaType
+	}
+	return "application/json"
+}
+
+func toProtoProduct(p Product) (*productpb.Product, error) {
+	attrs, err := structpb.NewStruct(p.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	pb := &productpb.Product{
+		Id:             int64(p.ID),
+		Name:           p.Name,
+		Price:          p.Price.Float64(),
+		Stock:          int64(p.Stock),
+		Category:       p.Category,
+		Sku:            p.SKU,
+		Tags:           p.Tags,
+		Attributes:     attrs,
+		AvailableStock: int64(p.AvailableStock),
+		Available:      p.Available,
+		Description:    p.Description,
+		Locale:         p.Locale,
+		UpdatedAt:      timestamppb.New(p.UpdatedAt.Time),
+	}
+	if p.CacheTTLSeconds != nil {
+		ttl := int32(*p.CacheTTLSeconds)
+		pb.CacheTtlSeconds = &ttl
+	}
+	return pb, nil
+}
+
+func marshalProductProto(p Product) ([]byte, error) {
+	pb, err := toProtoProduct(p)
+	if err != nil {
+		return nil, err
+	}
+	return protobufMarshal.Marshal(pb)
+}
+
+// marshalProductsProto memiliki signature yang sama dengan marshaller JSON di handleGetProducts
+func marshalProductsProto(v interface{}) ([]byte, error) {
+	products, ok := v.([]Product)
+	if !ok {
+		return nil, errors.New("protobuf hanya mendukung daftar produk")
+	}
+	list := &productpb.ProductList{Products: make([]*productpb.Product, 0, len(products))}
+	for _, p := range products {
+		pb, err := toProtoProduct(p)
+		if err != nil {
+			return nil, err
+		}
+		list.Products = append(list.Products, pb)
+	}
+	return protobufMarshal.Marshal(list)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+)
+
+// Peringatkan jika satu request menjalankan lebih dari sekian query (indikasi pola N+1)
+var queryCountWarnThreshold = 10
+
+type queryCounterKey struct{}
+
+// withQueryCounter menyisipkan penghitung query ke context request
+func withQueryCounter(c context.Context) (context.Context, *int64) {
+	n := new(int64)
+	return context.WithValue(c, queryCounterKey{}, n), n
+}
+
+func countQuery(c context.Context) {
+	if n, ok := c.Value(queryCounterKey{}).(*int64); ok {
+		atomic.AddInt64(n, 1)
+	}
+}
+
+// queryCountMiddleware menghitung query database per request dan memperingatkan pola N+1.
+// Hanya query yang memakai context request (QueryContext/ExecContext/...) yang terhitung.
+// Jumlahnya ikut dicatat oleh loggingMiddleware, yang memasang penghitungnya lebih dulu.
+func queryCountMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, ok := r.Context().Value(queryCounterKey{}).(*int64)
+		if !ok {
+			var c context.Context
+			c, n = withQueryCounter(r.Context())
+			r = r.WithContext(c)
+		}
+		next.ServeHTTP(w, r)
+
+		count := atomic.LoadInt64(n)
+		if queryCountWarnThreshold > 0 && count > int64(queryCountWarnThreshold) {
+			slog.WarnContext(r.Context(), "Jumlah query melewati ambang, kemungkinan pola N+1",
+				"method", r.Method, "path", r.URL.Path, "queries", count, "threshold", queryCountWarnThreshold)
+		}
+	})
+}
+
+// countingConnector membungkus connector driver Postgres agar setiap query/exec
+// dihitung pada penghitung di context. Selain itu semua pemanggilan diteruskan apa adanya.
+type countingConnector struct {
+	driver.Connector
+}
+
+func (c countingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &countingConn{conn}, nil
+}
+
+type countingConn struct {
+	driver.Conn
+}
+
+func (c *countingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	countQuery(ctx)
+	return q.QueryContext(ctx, query, args)
+}
+
+func (c *countingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	countQuery(ctx)
+	return e.ExecContext(ctx, query, args)
+}
+
+func (c *countingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *countingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *countingConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *countingConn) ResetSession(ctx context.Context) error {
+	if s, ok := c.Conn.(driver.SessionResetter); ok {
+		return s.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *countingConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+)
+
+// Kolom bobot yang diizinkan untuk ?weight= (whitelist, tidak pernah diambil mentah dari input)
+var randomWeightColumns = map[string]string{
+	"stock": "p.stock",
+	"price": "p.price",
+}
+
+// randomProductHandler memilih satu produk secara acak. Dengan ?weight=stock|price peluang
+// terpilih sebanding dengan bobotnya (metode Efraimidis-Spirakis: ORDER BY -ln(u)/bobot).
+// Produk berbobot 0 tidak pernah terpilih; jika semua berbobot 0 dipakai pemilihan seragam.
+func (a *App) randomProductHandler(w http.ResponseWriter, r *http.Request) {
+	weight := r.URL.Query().Get("weight")
+	column, ok := randomWeightColumns[weight]
+	if weight != "" && !ok {
+		writeJSONError(w, http.StatusBadRequest, "weight harus salah satu dari: stock, price")
+		return
+	}
+
+	var p Product
+	var err error
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	if ok {
+		sqlStatement := `SELECT ` + productColumns + ` FROM products p WHERE p.deleted_at IS NULL AND ` + column + ` > 0
+			ORDER BY -ln(1.0 - random()) / ` + column + ` LIMIT 1`
+		err = scanProduct(a.db.QueryRowContext(qc, sqlStatement), &p)
+	}
+	if !ok || errors.Is(err, sql.ErrNoRows) {
+		sqlStatement := `SELECT ` + productColumns + ` FROM products p WHERE p.deleted_at IS NULL ORDER BY random() LIMIT 1`
+		err = scanProduct(a.db.QueryRowContext(qc, sqlStatement), &p)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+		} else {
+			writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil produk")
+		}
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	jsoni.NewEncoder(w).Encode(p)
+}
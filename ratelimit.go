@@ -0,0 +1,157 @@
+package main
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/time/rate"
+)
+
+// Limiter per IP yang tidak dipakai selama ini dibuang agar map tidak tumbuh tanpa batas
+const rateLimitIdleTTL = 10 * time.Minute
+
+// Route yang tidak dibatasi: probe orkestrator dan scraping metrik datang dari IP yang sama terus-menerus
+var rateLimitExemptRoutes = map[string]bool{"healthz": true, "livez": true, "readyz": true, "metrics": true}
+
+// clientRateLimiter menyimpan satu token bucket per IP klien
+type clientRateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	mu      sync.Mutex
+	clients map[string]*rateLimitEntry
+}
+
+type rateLimitEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newClientRateLimiter(rps float64, burst int) *clientRateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	l := &clientRateLimiter{limit: rate.Limit(rps), burst: burst, clients: map[string]*rateLimitEntry{}}
+	go l.evictIdle()
+	return l
+}
+
+// reserve mengambil satu token; bila habis, mengembalikan berapa lama klien harus menunggu
+func (l *clientRateLimiter) reserve(client string) (time.Duration, bool) {
+	now := time.Now()
+	l.mu.Lock()
+	e, ok := l.clients[client]
+	if !ok {
+		e = &rateLimitEntry{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[client] = e
+	}
+	e.lastSeen = now
+	l.mu.Unlock()
+
+	res := e.limiter.ReserveN(now, 1)
+	if delay := res.DelayFrom(now); delay > 0 {
+		res.CancelAt(now)
+		return delay, false
+	}
+	return 0, true
+}
+
+func (l *clientRateLimiter) evictIdle() {
+	ticker := time.NewTicker(rateLimitIdleTTL)
+	defer ticker.Stop()
+	for range ticker.C {
+		cutoff := time.Now().Add(-rateLimitIdleTTL)
+		l.mu.Lock()
+		for client, e := range l.clients {
+			if e.lastSeen.Before(cutoff) {
+				delete(l.clients, client)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+// rateLimitConfig: GET/HEAD memakai batas baca yang lebih longgar, method lain batas tulis.
+// RPS 0 menonaktifkan batas untuk kelompok tersebut.
+type rateLimitConfig struct {
+	ReadRPS    float64
+	ReadBurst  int
+	WriteRPS   float64
+	WriteBurst int
+	TrustXFF   bool
+}
+
+func loadRateLimitConfig() rateLimitConfig {
+	return rateLimitConfig{
+		ReadRPS:    getEnvFloat("RATE_LIMIT_READ_RPS", 50),
+		ReadBurst:  getEnvInt("RATE_LIMIT_READ_BURST", 100),
+		WriteRPS:   getEnvFloat("RATE_LIMIT_WRITE_RPS", 10),
+		WriteBurst: getEnvInt("RATE_LIMIT_WRITE_BURST", 20),
+		// Hanya aktifkan di balik proxy tepercaya; selain itu X-Forwarded-For mudah dipalsukan
+		TrustXFF: getEnvBool("RATE_LIMIT_TRUST_X_FORWARDED_FOR", false),
+	}
+}
+
+// clientIP mengambil IP klien dari RemoteAddr, atau dari entri pertama X-Forwarded-For
+func clientIP(r *http.Request, trustXFF bool) string {
+	if trustXFF {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			first, _, _ := strings.Cut(xff, ",")
+			if ip := strings.TrimSpace(first); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimitMiddleware membatasi request per IP dengan token bucket dan menjawab 429 beserta
+// Retry-After bila token habis. Operasi yang diputar ulang dari antrean tulis tidak dibatasi.
+func rateLimitMiddleware(cfg rateLimitConfig) func(http.Handler) http.Handler {
+	var read, write *clientRateLimiter
+	if cfg.ReadRPS > 0 {
+		read = newClientRateLimiter(cfg.ReadRPS, cfg.ReadBurst)
+	}
+	if cfg.WriteRPS > 0 {
+		write = newClientRateLimiter(cfg.WriteRPS, cfg.WriteBurst)
+	}
+	return func(next http.Handler) http.Handler {
+		if read == nil && write == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route := mux.CurrentRoute(r); route != nil && rateLimitExemptRoutes[route.GetName()] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.Context().Value(replayKey{}) != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			limiter := write
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				limiter = read
+			}
+			if limiter == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if delay, ok := limiter.reserve(clientIP(r, cfg.TrustXFF)); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+				writeJSONError(w, http.StatusTooManyRequests, "Terlalu banyak request, coba lagi nanti")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        string
+		trustXFF   bool
+		want       string
+	}{
+		{name: "RemoteAddr IPv4", remoteAddr: "10.0.0.1:5000", want: "10.0.0.1"},
+		{name: "RemoteAddr IPv6", remoteAddr: "[2001:db8::1]:5000", want: "2001:db8::1"},
+		{name: "RemoteAddr tanpa port", remoteAddr: "10.0.0.1", want: "10.0.0.1"},
+		{name: "XFF diabaikan bila tidak dipercaya", remoteAddr: "10.0.0.1:5000", xff: "203.0.113.9", want: "10.0.0.1"},
+		{name: "XFF entri pertama", remoteAddr: "10.0.0.1:5000", xff: " 203.0.113.9 , 10.0.0.2", trustXFF: true, want: "203.0.113.9"},
+		{name: "XFF kosong jatuh ke RemoteAddr", remoteAddr: "10.0.0.1:5000", xff: " ,10.0.0.2", trustXFF: true, want: "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.xff != "" {
+				r.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if got := clientIP(r, tt.trustXFF); got != tt.want {
+				t.Fatalf("clientIP = %q, ingin %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	// RPS sangat kecil: setelah burst habis, token berikutnya tidak akan terisi selama test
+	h := rateLimitMiddleware(rateLimitConfig{ReadRPS: 0.001, ReadBurst: 2, WriteRPS: 0.001, WriteBurst: 1})(ok)
+	do := func(method, remoteAddr string, c context.Context) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/products/1", nil).WithContext(c)
+		r.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	bg := context.Background()
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if w := do(http.MethodGet, "10.0.0.1:1", bg); w.Code != want {
+			t.Fatalf("GET ke-%d: status %d, ingin %d", i+1, w.Code, want)
+		}
+	}
+	w := do(http.MethodGet, "10.0.0.1:1", bg)
+	if w.Header().Get("Retry-After") == "" {
+		t.Fatal("429 tanpa Retry-After")
+	}
+	// Batas dihitung per IP dan per kelompok method
+	if w := do(http.MethodGet, "10.0.0.2:1", bg); w.Code != http.StatusOK {
+		t.Fatalf("IP lain ikut dibatasi: %d", w.Code)
+	}
+	if w := do(http.MethodPost, "10.0.0.1:1", bg); w.Code != http.StatusOK {
+		t.Fatalf("batas tulis ikut habis oleh GET: %d", w.Code)
+	}
+	if w := do(http.MethodPost, "10.0.0.1:1", bg); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("POST kedua: status %d, ingin 429", w.Code)
+	}
+	// Replay antrean tulis tidak dibatasi
+	if w := do(http.MethodPost, "10.0.0.1:1", context.WithValue(bg, replayKey{}, true)); w.Code != http.StatusOK {
+		t.Fatalf("replay dibatasi: %d", w.Code)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Reservasi stok (mis. barang di keranjang pembeli lain) disimpan di Redis, satu sorted set
+// per produk: member "<token>:<jumlah>", score = waktu kedaluwarsa (unix ms). Reservasi yang
+// lewat waktunya otomatis tidak dihitung dan dibersihkan saat dibaca.
+func reservationsKey(productID int) string {
+	return "reservations:product:" + strconv.Itoa(productID)
+}
+
+// addReservation mencatat reservasi qty unit untuk token sampai ttl habis
+func (a *App) addReservation(c context.Context, productID int, token string, qty int, ttl time.Duration) error {
+	key := reservationsKey(productID)
+	expires := time.Now().Add(ttl)
+	pipe := a.rdb.TxPipeline()
+	pipe.ZAdd(c, key, &redis.Z{Score: float64(expires.UnixMilli()), Member: token + ":" + strconv.Itoa(qty)})
+	// Kunci hidup setidaknya selama reservasi terakhir
+	pipe.ExpireAt(c, key, expires.Add(time.Minute))
+	_, err := pipe.Exec(c)
+	return err
+}
+
+// removeReservation menghapus reservasi token (konfirmasi atau pembatalan)
+func (a *App) removeReservation(c context.Context, productID int, token string) error {
+	key := reservationsKey(productID)
+	members, err := a.rdb.ZRange(c, key, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	for _, m := range members {
+		if strings.HasPrefix(m, token+":") {
+			if err := a.rdb.ZRem(c, key, m).Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reservedQuantity menjumlahkan reservasi produk yang masih aktif
+func (a *App) reservedQuantity(c context.Context, productID int) (int, error) {
+	key := reservationsKey(productID)
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	a.rdb.ZRemRangeByScore(c, key, "-inf", now)
+	members, err := a.rdb.ZRangeByScore(c, key, &redis.ZRangeBy{Min: "(" + now, Max: "+inf"}).Result()
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, m := range members {
+		if i := strings.LastIndexByte(m, ':'); i >= 0 {
+			if n, err := strconv.Atoi(m[i+1:]); err == nil {
+				total += n
+			}
+		}
+	}
+	return total, nil
+}
+
+type stockBreakdown struct {
+	Total     int `json:"total"`
+	Reserved  int `json:"reserved"`
+	Available int `json:"available"`
+}
+
+// productWithStockBreakdown mengganti field "stock" menjadi rincian total/reserved/available.
+// Hanya dipakai bila klien meminta ?stock_breakdown=true agar respons lama tetap kompatibel.
+type productWithStockBreakdown struct {
+	Product
+	Stock stockBreakdown `json:"stock"`
+}
+
+// withStockBreakdown menghitung stok yang benar-benar dapat dibeli: stok tersedia (termasuk
+// varian) dikurangi reservasi aktif. availableStock dan available ikut angka tersebut.
+func (a *App) withStockBreakdown(c context.Context, p Product) (productWithStockBreakdown, error) {
+	reserved, err := a.reservedQuantity(c, p.ID)
+	if err != nil {
+		return productWithStockBreakdown{}, err
+	}
+	total := p.AvailableStock
+	available := total - reserved
+	if available < 0 {
+		available = 0
+	}
+	p.AvailableStock = available
+	p.Available = available > 0
+	return productWithStockBreakdown{
+		Product: p,
+		Stock:   stockBreakdown{Total: total, Reserved: reserved, Available: available},
+	}, nil
+}
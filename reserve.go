@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// POST /products/{id}/reserve mengurangi stok di database secara langsung untuk checkout.
+// Berbeda dengan hold (holds.go) yang menahan stok sementara di Redis, reservasi ini final.
+// Aturannya sama dengan createHoldHandler: baris dikunci FOR UPDATE, lalu hanya stok dikurangi
+// reservasi aktif (hold, keranjang) yang boleh diambil, sehingga reserve tidak pernah memakan
+// stok yang sudah dijanjikan ke hold yang belum dikonfirmasi.
+
+type reserveResult struct {
+	ID       int `json:"id"`
+	Reserved int `json:"reserved"`
+	Stock    int `json:"stock"`
+}
+
+// reserveStockHandler membalas 409 beserta stok yang tersedia bila quantity melebihinya
+func (a *App) reserveStockHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "id produk tidak valid")
+		return
+	}
+	body, err := readRequestBody(w, r)
+	if err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	var payload struct {
+		Quantity int `json:"quantity"`
+	}
+	dec := jsoni.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&payload); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if payload.Quantity <= 0 {
+		writeJSONError(w, http.StatusBadRequest, "quantity harus lebih dari 0")
+		return
+	}
+
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	tx, err := a.db.BeginTx(qc, nil)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mereservasi stok")
+		return
+	}
+	defer tx.Rollback()
+	var stock int
+	var category string
+	var threshold sql.NullInt64
+	err = tx.QueryRowContext(qc, `SELECT stock, category, low_stock_threshold FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).
+		Scan(&stock, &category, &threshold)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSONError(w, http.StatusNotFound, "Produk tidak ditemukan")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mereservasi stok")
+		return
+	}
+	// Dibaca selagi baris terkunci: hold baru untuk produk ini menunggu (SKIP LOCKED → busy)
+	reserved, err := a.reservedQuantity(r.Context(), id)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Gagal membaca reservasi produk", "product_id", id, "err", err)
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mereservasi stok")
+		return
+	}
+	if available := stock - reserved; available < payload.Quantity {
+		writeReserveConflict(w, max(available, 0))
+		return
+	}
+
+	var remaining int
+	var updatedAt time.Time
+	// stock >= $1 adalah pengaman kedua: tanpa kunci baris pun UPDATE tidak bisa membuat stok negatif
+	err = tx.QueryRowContext(qc, `UPDATE products SET stock = stock - $1 WHERE id = $2 AND stock >= $1 RETURNING stock, updated_at`, payload.Quantity, id).
+		Scan(&remaining, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Stok berubah di luar kunci baris; laporkan sisa terbaru bila masih bisa dibaca
+		var current int
+		if err := tx.QueryRowContext(qc, `SELECT stock FROM products WHERE id = $1`, id).Scan(&current); err != nil {
+			current = 0
+		}
+		writeReserveConflict(w, max(current-reserved, 0))
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mereservasi stok")
+		return
+	}
+	if err := recordAudit(qc, tx, id, stockChange(stock, remaining)); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mencatat riwayat stok")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mereservasi stok")
+		return
+	}
+	a.invalidateProductCaches(r.Context(), id, category)
+	a.notifyLowStock(r.Context(), id, stock, remaining, threshold)
+	w.Header().Set("ETag", productETag(updatedAt))
+	w.Header().Set("Content-Type", "application/json")
+	jsoni.NewEncoder(w).Encode(reserveResult{ID: id, Reserved: payload.Quantity, Stock: remaining})
+}
+
+// writeReserveConflict membalas 409 dengan stok yang masih bisa diambil (sudah dikurangi reservasi)
+func writeReserveConflict(w http.ResponseWriter, available int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	jsoni.NewEncoder(w).Encode(map[string]interface{}{
+		"error":     "Stok tidak mencukupi",
+		"available": available,
+	})
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestReserveStockRespectsReservations(t *testing.T) {
+	tests := []struct {
+		name          string
+		stock         int
+		held          int
+		quantity      int
+		wantStatus    int
+		wantAvailable int
+	}{
+		{name: "stok bebas cukup", stock: 5, held: 2, quantity: 3, wantStatus: http.StatusOK},
+		{name: "seluruh stok ditahan hold", stock: 5, held: 5, quantity: 5, wantStatus: http.StatusConflict, wantAvailable: 0},
+		{name: "sebagian ditahan", stock: 5, held: 3, quantity: 3, wantStatus: http.StatusConflict, wantAvailable: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, mock, _ := newTestApp(t)
+			if tt.held > 0 {
+				if err := a.addReservation(context.Background(), 7, "hold-1", tt.held, time.Minute); err != nil {
+					t.Fatal(err)
+				}
+			}
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT stock, category, low_stock_threshold FROM products WHERE id = \$1 AND deleted_at IS NULL FOR UPDATE`).
+				WithArgs(7).
+				WillReturnRows(sqlmock.NewRows([]string{"stock", "category", "low_stock_threshold"}).AddRow(tt.stock, "alat", nil))
+			if tt.wantStatus == http.StatusOK {
+				remaining := tt.stock - tt.quantity
+				mock.ExpectQuery(`UPDATE products SET stock = stock - \$1 WHERE id = \$2 AND stock >= \$1`).
+					WithArgs(tt.quantity, 7).
+					WillReturnRows(sqlmock.NewRows([]string{"stock", "updated_at"}).AddRow(remaining, testProductTime))
+				mock.ExpectExec(`INSERT INTO audit_log`).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/products/7/reserve", strings.NewReader(`{"quantity":`+strconv.Itoa(tt.quantity)+`}`))
+			w := httptest.NewRecorder()
+			a.newRouter(trailingSlashIgnore, false).ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status %d, ingin %d; body: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusConflict {
+				var body struct {
+					Available int `json:"available"`
+				}
+				if err := jsoni.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Available != tt.wantAvailable {
+					t.Fatalf("available %d, ingin %d (%v)", body.Available, tt.wantAvailable, err)
+				}
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// Bila stok sudah berkurang di luar kunci baris, UPDATE dengan stock >= $1 tidak mengubah
+// apa pun dan request dijawab 409, bukan mengurangi stok sampai negatif
+func TestReserveStockUpdateGuard(t *testing.T) {
+	a, mock, _ := newTestApp(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT stock, category, low_stock_threshold FROM products`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"stock", "category", "low_stock_threshold"}).AddRow(5, "alat", nil))
+	mock.ExpectQuery(`UPDATE products SET stock = stock - \$1 WHERE id = \$2 AND stock >= \$1`).
+		WithArgs(3, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"stock", "updated_at"}))
+	mock.ExpectQuery(`SELECT stock FROM products WHERE id = \$1`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"stock"}).AddRow(1))
+	mock.ExpectRollback()
+
+	req := httptest.NewRequest(http.MethodPost, "/products/7/reserve", strings.NewReader(`{"quantity":3}`))
+	w := httptest.NewRecorder()
+	a.newRouter(trailingSlashIgnore, false).ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status %d, ingin 409; body: %s", w.Code, w.Body)
+	}
+	var body struct {
+		Available int `json:"available"`
+	}
+	if err := jsoni.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Available != 1 {
+		t.Fatalf("available %d, ingin 1 (%v)", body.Available, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
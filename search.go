@@ -0,0 +1,113 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Ukuran batch reindex; batch kecil menjaga lock baris tetap singkat
+const defaultReindexBatchSize = 500
+
+type reindexRequest struct {
+	IDs       []int  `json:"ids,omitempty"`
+	Category  string `json:"category,omitempty"`
+	BatchSize int    `json:"batchSize,omitempty"`
+}
+
+type reindexProgress struct {
+	Batch   int    `json:"batch"`
+	Updated int    `json:"updated"`
+	Total   int    `json:"total"`
+	LastID  int    `json:"lastId"`
+	Done    bool   `json:"done"`
+	Elapsed string `json:"elapsed"`
+}
+
+// reindexSearchHandler (admin) menghitung ulang search_vector untuk semua produk, atau
+// subset lewat body {"ids":[...]} / {"category":"..."}. Berjalan per batch berdasarkan id,
+// tiap batch adalah transaksi terpisah. Progres dialirkan sebagai NDJSON, satu baris per batch.
+func (a *App) reindexSearchHandler(w http.ResponseWriter, r *http.Request) {
+	var req reindexRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSONGuarded(r, &req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if req.BatchSize <= 0 || req.BatchSize > 10000 {
+		req.BatchSize = defaultReindexBatchSize
+	}
+
+	args := []interface{}{}
+	conds := ""
+	if len(req.IDs) > 0 {
+		args = append(args, pq.Array(req.IDs))
+		conds += " AND id = ANY($" + strconv.Itoa(len(args)) + ")"
+	}
+	if req.Category != "" {
+		args = append(args, req.Category)
+		conds += " AND category = $" + strconv.Itoa(len(args))
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	enc := jsoni.NewEncoder(w)
+
+	start := time.Now()
+	progress := reindexProgress{}
+	for {
+		batchArgs := append(append([]interface{}{}, args...), progress.LastID, req.BatchSize)
+		n := len(batchArgs)
+		sqlStatement := `WITH batch AS (
+				SELECT id FROM products WHERE id > $` + strconv.Itoa(n-1) + conds + `
+				ORDER BY id LIMIT $` + strconv.Itoa(n) + `
+			)
+			UPDATE products p SET search_vector = products_search_vector(p.name, p.category, p.tags)
+			FROM batch WHERE p.id = batch.id
+			RETURNING p.id`
+		// Setiap batch dibatasi sendiri; reindex penuh boleh berjalan selama batas route
+		qc, cancel := withQueryTimeout(r.Context())
+		rows, err := a.db.QueryContext(qc, sqlStatement, batchArgs...)
+		if err != nil {
+			cancel()
+			slog.ErrorContext(r.Context(), "Reindex pencarian gagal", "last_id", progress.LastID, "err", err)
+			if progress.Batch == 0 {
+				writeJSONError(w, http.StatusInternalServerError, "Gagal melakukan reindex")
+			}
+			return
+		}
+		updated := 0
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err == nil && id > progress.LastID {
+				progress.LastID = id
+			}
+			updated++
+		}
+		err = rows.Err()
+		rows.Close()
+		cancel()
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Reindex pencarian gagal", "last_id", progress.LastID, "err", err)
+			return
+		}
+
+		progress.Batch++
+		progress.Updated = updated
+		progress.Total += updated
+		progress.Done = updated < req.BatchSize
+		progress.Elapsed = time.Since(start).String()
+		enc.Encode(progress)
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if progress.Done {
+			slog.InfoContext(r.Context(), "Reindex pencarian selesai", "products", progress.Total, "batches", progress.Batch, "duration", time.Since(start))
+			return
+		}
+	}
+}
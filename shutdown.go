@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// shutdownHook menguras pekerjaan async yang masih tertahan (buffer channel, goroutine
+// background) setelah server berhenti menerima request. Hook harus berhenti saat ctx habis.
+type shutdownHook struct {
+	name string
+	fn   func(c context.Context) error
+}
+
+var (
+	shutdownHooksMu sync.Mutex
+	shutdownHooks   []shutdownHook
+)
+
+// registerShutdownHook dipanggil oleh setiap worker async saat dijalankan
+func registerShutdownHook(name string, fn func(c context.Context) error) {
+	shutdownHooksMu.Lock()
+	defer shutdownHooksMu.Unlock()
+	shutdownHooks = append(shutdownHooks, shutdownHook{name: name, fn: fn})
+}
+
+// runShutdownHooks menjalankan semua hook secara paralel dengan batas waktu bersama.
+// Dipanggil setelah server.Shutdown selesai, sebelum koneksi DB/Redis ditutup.
+func runShutdownHooks(timeout time.Duration) {
+	shutdownHooksMu.Lock()
+	hooks := append([]shutdownHook(nil), shutdownHooks...)
+	shutdownHooksMu.Unlock()
+
+	c, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, h := range hooks {
+		wg.Add(1)
+		go func(h shutdownHook) {
+			defer wg.Done()
+			start := time.Now()
+			if err := h.fn(c); err != nil {
+				slog.Error("Shutdown hook gagal", "hook", h.name, "err", err)
+				return
+			}
+			slog.Info("Shutdown hook selesai", "hook", h.name, "duration", time.Since(start))
+		}(h)
+	}
+	wg.Wait()
+}
+
+// waitGroupDrain membuat fungsi hook yang menunggu WaitGroup atau sampai ctx habis
+func waitGroupDrain(wg *sync.WaitGroup) func(c context.Context) error {
+	return func(c context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-c.Done():
+			return c.Err()
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// putProductBySKUHandler membuat produk jika belum ada produk dengan SKU tersebut (201),
+// atau mengembalikan produk yang sudah ada tanpa mengubahnya (200).
+// Aman diulang oleh skrip provisioning; cache hanya diinvalidasi bila produk benar-benar dibuat.
+func (a *App) putProductBySKUHandler(w http.ResponseWriter, r *http.Request) {
+	sku := strings.TrimSpace(mux.Vars(r)["sku"])
+	if sku == "" {
+		writeJSONError(w, http.StatusBadRequest, "sku wajib diisi")
+		return
+	}
+	body, err := readRequestBody(w, r)
+	if err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	p, err := decodeNewProduct(bytes.NewReader(body))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if p.SKU != "" && strings.TrimSpace(p.SKU) != sku {
+		writeJSONError(w, http.StatusBadRequest, "sku pada body tidak sama dengan sku pada URL")
+		return
+	}
+	p.SKU = sku
+	if err := validateProduct(p); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validatePrice(p.Price); err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	attrs, err := normalizeNewProduct(&p)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Atribut produk tidak valid")
+		return
+	}
+
+	// ON CONFLICT DO NOTHING tidak mengembalikan baris jika SKU sudah ada
+	sqlStatement := `INSERT INTO products AS p (name, price, stock, category, sku, tags, attributes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (sku) WHERE deleted_at IS NULL DO NOTHING RETURNING ` + productColumns
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	rows, err := a.db.QueryContext(qc, sqlStatement, p.Name, p.Price, p.Stock, p.Category, p.SKU, pq.Array(p.Tags), attrs)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal membuat produk")
+		return
+	}
+	created := rows.Next()
+	if created {
+		err = scanProduct(rows, &p)
+	}
+	rows.Close()
+	if err == nil {
+		err = rows.Err()
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal membuat produk")
+		return
+	}
+
+	if created {
+		a.afterProductCreated(r.Context(), &p)
+		writeCreatedProduct(w, p)
+		return
+	}
+
+	var existing Product
+	sqlStatement = `SELECT ` + productColumns + ` FROM products p WHERE p.sku = $1 AND p.deleted_at IS NULL`
+	if err := scanProduct(a.db.QueryRowContext(qc, sqlStatement, sku), &existing); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil produk")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	jsoni.NewEncoder(w).Encode(existing)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Cache stok per produk (stock:{id}) yang terpisah dari cache detail, berisi satu angka saja.
+// TTL dibuat singkat karena stok sering berubah; invalidasi ikut invalidateProductCache.
+var stockLevelCacheTTL = 30 * time.Second
+
+func stockLevelCacheKey(id int) string {
+	return "stock:" + strconv.Itoa(id)
+}
+
+// stockLevelsHandler mengembalikan peta id -> stok tersedia untuk polling berfrekuensi tinggi.
+// Id yang tidak ditemukan tidak muncul di hasil.
+func (a *App) stockLevelsHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs []int `json:"ids"`
+	}
+	if err := decodeJSONGuarded(r, &req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(req.IDs) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "ids tidak boleh kosong")
+		return
+	}
+	if len(req.IDs) > maxAvailabilityItems {
+		writeJSONError(w, http.StatusBadRequest, "Terlalu banyak id dalam satu permintaan")
+		return
+	}
+
+	levels := make(map[string]int, len(req.IDs))
+	keys := make([]string, len(req.IDs))
+	for i, id := range req.IDs {
+		keys[i] = stockLevelCacheKey(id)
+	}
+	var missing []int
+	cached, err := a.rdb.MGet(r.Context(), keys...).Result()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Gagal membaca cache stok", "err", err)
+		missing = req.IDs
+	} else {
+		for i, v := range cached {
+			s, ok := v.(string)
+			if n, err := strconv.Atoi(s); ok && err == nil {
+				levels[strconv.Itoa(req.IDs[i])] = n
+			} else {
+				missing = append(missing, req.IDs[i])
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		qc, cancel := withQueryTimeout(r.Context())
+		defer cancel()
+		rows, err := a.db.QueryContext(qc,
+			`SELECT p.id, COALESCE((SELECT SUM(v.stock) FROM product_variants v WHERE v.product_id = p.id), p.stock)
+			FROM products p WHERE p.id = ANY($1) AND p.deleted_at IS NULL`, pq.Array(missing))
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil stok")
+			return
+		}
+		defer rows.Close()
+		pipe := a.rdb.Pipeline()
+		for rows.Next() {
+			var id, stock int
+			if err := rows.Scan(&id, &stock); err != nil {
+				writeJSONError(w, http.StatusInternalServerError, "Gagal memindai data stok")
+				return
+			}
+			levels[strconv.Itoa(id)] = stock
+			pipe.Set(r.Context(), stockLevelCacheKey(id), stock, stockLevelCacheTTL)
+		}
+		if err := rows.Err(); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil stok")
+			return
+		}
+		if _, err := pipe.Exec(r.Context()); err != nil {
+			slog.ErrorContext(r.Context(), "Gagal menyimpan cache stok", "err", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	jsoni.NewEncoder(w).Encode(levels)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Batas jumlah produk per operasi tag massal
+const maxBulkTagIDs = 1000
+
+// normalizeTags memangkas spasi, membuang tag kosong/duplikat, dan mengurutkan hasilnya
+func normalizeTags(tags []string) []string {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
+
+type bulkTagRequest struct {
+	IDs    []int    `json:"ids"`
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+// bulkTagHandler menambah/menghapus tag pada banyak produk sekaligus dalam satu transaksi.
+// Tag yang ada di "add" sekaligus "remove" akhirnya dihapus.
+func (a *App) bulkTagHandler(w http.ResponseWriter, r *http.Request) {
+	var req bulkTagRequest
+	if err := decodeJSONGuarded(r, &req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	add, remove := normalizeTags(req.Add), normalizeTags(req.Remove)
+	if len(req.IDs) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "ids tidak boleh kosong")
+		return
+	}
+	if len(req.IDs) > maxBulkTagIDs {
+		writeJSONError(w, http.StatusBadRequest, "Terlalu banyak produk dalam satu permintaan")
+		return
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "add atau remove wajib diisi")
+		return
+	}
+
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	tx, err := a.db.BeginTx(qc, nil)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui tag")
+		return
+	}
+	defer tx.Rollback()
+
+	// Gabungkan, buang duplikat dan tag yang dihapus, lalu urutkan agar hasil deterministik
+	sqlStatement := `UPDATE products SET tags = ARRAY(
+			SELECT DISTINCT t FROM unnest(array_cat(tags, $2::text[])) AS t
+			WHERE NOT (t = ANY($3::text[])) ORDER BY t)
+		WHERE id = ANY($1) AND deleted_at IS NULL`
+	res, err := tx.ExecContext(qc, sqlStatement, pq.Array(req.IDs), pq.Array(add), pq.Array(remove))
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui tag")
+		return
+	}
+	affected, _ := res.RowsAffected()
+	if err := tx.Commit(); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui tag")
+		return
+	}
+
+	if affected > 0 {
+		a.invalidateManyProductCaches(r.Context(), req.IDs)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	jsoni.NewEncoder(w).Encode(map[string]int64{"affected": affected})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// timeoutConfig memetakan nama route (lihat .Name(...) di main) ke batas waktu request.
+// Route tanpa override memakai Default.
+type timeoutConfig struct {
+	Default  time.Duration
+	PerRoute map[string]time.Duration
+}
+
+// Override bawaan untuk route yang memang lama; dapat ditimpa lewat ROUTE_TIMEOUTS
+var defaultRouteTimeouts = map[string]time.Duration{
+	"export-jsonl":         5 * time.Minute,
+	"export-products":      5 * time.Minute,
+	"admin-search-reindex": 10 * time.Minute,
+}
+
+// loadTimeoutConfig membaca REQUEST_TIMEOUT (default 10s) dan
+// ROUTE_TIMEOUTS, mis. "export-jsonl=10m,get-product=2s".
+func loadTimeoutConfig() timeoutConfig {
+	cfg := timeoutConfig{
+		Default:  getEnvDuration("REQUEST_TIMEOUT", 10*time.Second),
+		PerRoute: map[string]time.Duration{},
+	}
+	for name, d := range defaultRouteTimeouts {
+		cfg.PerRoute[name] = d
+	}
+	for _, entry := range strings.Split(getEnv("ROUTE_TIMEOUTS", ""), ",") {
+		name, raw, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil || d < 0 {
+			slog.Warn("ROUTE_TIMEOUTS: durasi tidak valid", "route", name, "value", raw)
+			continue
+		}
+		cfg.PerRoute[strings.TrimSpace(name)] = d
+	}
+	return cfg
+}
+
+func (cfg timeoutConfig) forRoute(name string) time.Duration {
+	if d, ok := cfg.PerRoute[name]; ok {
+		return d
+	}
+	return cfg.Default
+}
+
+// timeoutMiddleware memasang deadline pada context request sesuai route yang cocok,
+// sehingga query database ikut dibatalkan. Durasi 0 berarti tanpa batas waktu.
+func timeoutMiddleware(cfg timeoutConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var name string
+			if route := mux.CurrentRoute(r); route != nil {
+				name = route.GetName()
+			}
+			d := cfg.forRoute(name)
+			if d <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			c, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(c))
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type productTranslation struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag bahasa BCP 47 sederhana, disimpan dalam huruf kecil (mis. "id", "en-gb")
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// Batas jumlah bahasa dari Accept-Language yang dipertimbangkan (juga membatasi variasi kunci cache)
+const maxAcceptLanguages = 3
+
+// parseAcceptLanguage mengembalikan tag bahasa dari header Accept-Language, diurutkan
+// menurut q (stabil untuk q yang sama). Tag dengan q=0 dan "*" diabaikan.
+func parseAcceptLanguage(header string) []string {
+	type pref struct {
+		tag string
+		q   float64
+	}
+	var prefs []pref
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" || !localePattern.MatchString(tag) {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		prefs = append(prefs, pref{tag, q})
+	}
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+
+	var tags []string
+	for _, p := range prefs {
+		if len(tags) == maxAcceptLanguages {
+			break
+		}
+		tags = append(tags, p.tag)
+	}
+	return tags
+}
+
+// localizeProduct mengganti nama (dan mengisi deskripsi) dengan terjemahan pertama yang cocok
+// dengan preferensi: tag persis, lalu subtag utama ("id-id" -> "id"). Tanpa kecocokan,
+// nama dasar tetap dipakai.
+func localizeProduct(p *Product, locales []string) {
+	if len(locales) == 0 || len(p.Translations) == 0 {
+		return
+	}
+	for _, locale := range locales {
+		candidates := []string{locale}
+		if primary, _, ok := strings.Cut(locale, "-"); ok {
+			candidates = append(candidates, primary)
+		}
+		for _, c := range candidates {
+			if t, ok := p.Translations[c]; ok && t.Name != "" {
+				p.Name = t.Name
+				p.Description = t.Description
+				p.Locale = c
+				return
+			}
+		}
+	}
+}
+
+func localizeProducts(products []Product, locales []string) {
+	for i := range products {
+		localizeProduct(&products[i], locales)
+	}
+}
+
+func parseTranslationVars(r *http.Request) (int, string, error) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return 0, "", errors.New("id produk tidak valid")
+	}
+	locale := strings.ToLower(vars["locale"])
+	if locale != "" && !localePattern.MatchString(locale) {
+		return 0, "", errors.New("locale tidak valid, gunakan tag bahasa seperti id atau en-GB")
+	}
+	return id, locale, nil
+}
+
+// listTranslationsHandler mengembalikan semua terjemahan produk
+func (a *App) listTranslationsHandler(w http.ResponseWriter, r *http.Request) {
+	id, _, err := parseTranslationVars(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var raw []byte
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	err = a.db.QueryRowContext(qc, `SELECT translations FROM products WHERE id = $1 AND deleted_at IS NULL`, id).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil terjemahan")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(raw)
+}
+
+// putTranslationHandler menambah atau mengganti terjemahan untuk satu locale
+func (a *App) putTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	id, locale, err := parseTranslationVars(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var t productTranslation
+	if err := jsoni.NewDecoder(r.Body).Decode(&t); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		writeJSONError(w, http.StatusBadRequest, "name terjemahan tidak boleh kosong")
+		return
+	}
+	value, err := jsoni.Marshal(t)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Terjemahan tidak valid")
+		return
+	}
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	res, err := a.db.ExecContext(qc,
+		`UPDATE products SET translations = translations || jsonb_build_object($1::text, $2::jsonb) WHERE id = $3 AND deleted_at IS NULL`,
+		locale, string(value), id)
+	if !a.translationUpdated(w, r, id, res, err) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	jsoni.NewEncoder(w).Encode(t)
+}
+
+// deleteTranslationHandler menghapus terjemahan satu locale
+func (a *App) deleteTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	id, locale, err := parseTranslationVars(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	res, err := a.db.ExecContext(qc, `UPDATE products SET translations = translations - $1::text WHERE id = $2 AND deleted_at IS NULL`, locale, id)
+	if !a.translationUpdated(w, r, id, res, err) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// translationUpdated menangani hasil update terjemahan dan membersihkan cache produk
+func (a *App) translationUpdated(w http.ResponseWriter, r *http.Request, id int, res sql.Result, err error) bool {
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Gagal memperbarui terjemahan", "err", err)
+		writeJSONError(w, http.StatusInternalServerError, "Gagal memperbarui terjemahan")
+		return false
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+		return false
+	}
+	a.invalidateProductCaches(r.Context(), id)
+	return true
+}
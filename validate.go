@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Batas atas harga (MAX_PRICE). Kolom price juga dibatasi CHECK di migrasi 000011,
+// jadi nilai di atas 1e9 tetap ditolak database meskipun env dinaikkan.
+var maxPrice = 1e9
+
+// validatePrice menolak harga negatif dan di atas maxPrice (dijawab 422). NaN/Inf dan lebih
+// dari dua angka desimal sudah ditolak saat decode JSON ke Money.
+func validatePrice(price Money) error {
+	if price < 0 {
+		return errors.New("harga tidak boleh negatif")
+	}
+	if price.Float64() > maxPrice {
+		return errors.New("harga tidak boleh lebih dari " + strconv.FormatFloat(maxPrice, 'f', -1, 64))
+	}
+	return nil
+}
+
+// validateProduct memeriksa field wajib sebelum produk disimpan (create dan PUT), dijawab 400.
+// Harga tidak diperiksa di sini: semua batas harga, termasuk negatif, dijawab 422 oleh validatePrice.
+func validateProduct(p Product) error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("name wajib diisi")
+	}
+	if p.Stock < 0 {
+		return errors.New("stok tidak boleh negatif")
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Harga negatif dijawab 422 seperti harga di atas MAX_PRICE; field wajib lain tetap 400.
+// Tidak ada query yang diharapkan: validasi terjadi sebelum database disentuh.
+func TestProductValidationStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "create harga negatif", method: http.MethodPost, path: "/products", body: `{"name":"a","price":-1,"stock":1}`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "create harga terlalu besar", method: http.MethodPost, path: "/products", body: `{"name":"a","price":10000000000,"stock":1}`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "create nama kosong", method: http.MethodPost, path: "/products", body: `{"name":" ","price":1,"stock":1}`, wantStatus: http.StatusBadRequest},
+		{name: "create stok negatif", method: http.MethodPost, path: "/products", body: `{"name":"a","price":1,"stock":-1}`, wantStatus: http.StatusBadRequest},
+		{name: "put harga negatif", method: http.MethodPut, path: "/products/1", body: `{"name":"a","price":-1,"stock":1}`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "put by sku harga negatif", method: http.MethodPut, path: "/products/sku/ABC", body: `{"name":"a","price":-1,"stock":1}`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "batch harga negatif", method: http.MethodPost, path: "/products/batch", body: `[{"name":"a","price":-1,"stock":1}]`, wantStatus: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, mock, _ := newTestApp(t)
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			a.newRouter(trailingSlashIgnore, false).ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status %d, ingin %d; body: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
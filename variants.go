@@ -0,0 +1,238 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// ProductVariant adalah turunan produk (mis. ukuran/warna) dengan SKU dan stok sendiri
+type ProductVariant struct {
+	ID         int       `json:"id"`
+	ProductID  int       `json:"productId"`
+	SKU        string    `json:"sku"`
+	Attributes SortedMap `json:"attributes"`
+	Stock      int       `json:"stock"`
+}
+
+const variantColumns = `id, product_id, sku, attributes, stock`
+
+func scanVariant(row rowScanner, v *ProductVariant) error {
+	var attrs []byte
+	if err := row.Scan(&v.ID, &v.ProductID, &v.SKU, &attrs, &v.Stock); err != nil {
+		return err
+	}
+	v.Attributes = SortedMap{}
+	return jsoni.Unmarshal(attrs, &v.Attributes)
+}
+
+// Kode error PostgreSQL yang relevan untuk varian
+const (
+	pqForeignKeyViolation = "23503"
+	pqUniqueViolation     = "23505"
+)
+
+func isPQError(err error, code string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && string(pqErr.Code) == code
+}
+
+// parseVariantVars membaca {id} dan {variantId} dari URL
+func parseVariantVars(r *http.Request) (productID, variantID int, err error) {
+	vars := mux.Vars(r)
+	if productID, err = strconv.Atoi(vars["id"]); err != nil {
+		return 0, 0, errors.New("id produk tidak valid")
+	}
+	if s, ok := vars["variantId"]; ok {
+		if variantID, err = strconv.Atoi(s); err != nil {
+			return 0, 0, errors.New("id varian tidak valid")
+		}
+	}
+	return productID, variantID, nil
+}
+
+func decodeVariant(r *http.Request) (ProductVariant, error) {
+	var v ProductVariant
+	if err := jsoni.NewDecoder(r.Body).Decode(&v); err != nil {
+		return v, err
+	}
+	v.SKU = strings.TrimSpace(v.SKU)
+	if v.SKU == "" {
+		return v, errors.New("sku wajib diisi")
+	}
+	if v.Stock < 0 {
+		return v, errors.New("stok tidak boleh negatif")
+	}
+	if v.Attributes == nil {
+		v.Attributes = SortedMap{}
+	}
+	return v, nil
+}
+
+func writeVariantWriteError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case isPQError(err, pqForeignKeyViolation):
+		writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+	case isPQError(err, pqUniqueViolation):
+		writeJSONError(w, http.StatusConflict, "SKU sudah dipakai")
+	default:
+		writeJSONError(w, http.StatusInternalServerError, "Gagal menyimpan varian")
+	}
+}
+
+func (a *App) listVariantsHandler(w http.ResponseWriter, r *http.Request) {
+	productID, _, err := parseVariantVars(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var exists bool
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	if err := a.db.QueryRowContext(qc, `SELECT EXISTS(SELECT 1 FROM products WHERE id=$1 AND deleted_at IS NULL)`, productID).Scan(&exists); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil varian")
+		return
+	}
+	if !exists {
+		writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+		return
+	}
+
+	rows, err := a.db.QueryContext(qc, `SELECT `+variantColumns+` FROM product_variants WHERE product_id=$1 ORDER BY id`, productID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil varian")
+		return
+	}
+	defer rows.Close()
+
+	variants := make([]ProductVariant, 0)
+	for rows.Next() {
+		var v ProductVariant
+		if err := scanVariant(rows, &v); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Gagal memindai data varian")
+			return
+		}
+		variants = append(variants, v)
+	}
+	if err := rows.Err(); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil varian")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	jsoni.NewEncoder(w).Encode(variants)
+}
+
+func (a *App) createVariantHandler(w http.ResponseWriter, r *http.Request) {
+	productID, _, err := parseVariantVars(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	v, err := decodeVariant(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	attrs, err := jsoni.Marshal(v.Attributes)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Atribut varian tidak valid")
+		return
+	}
+	v.ProductID = productID
+	sqlStatement := `INSERT INTO product_variants (product_id, sku, attributes, stock) VALUES ($1, $2, $3, $4) RETURNING id`
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	if err := a.db.QueryRowContext(qc, sqlStatement, productID, v.SKU, attrs, v.Stock).Scan(&v.ID); err != nil {
+		writeVariantWriteError(w, r, err)
+		return
+	}
+	a.invalidateProductCaches(r.Context(), productID)
+	a.invalidateCategoryStockForProduct(r.Context(), productID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	jsoni.NewEncoder(w).Encode(v)
+}
+
+func (a *App) getVariantHandler(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, err := parseVariantVars(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var v ProductVariant
+	sqlStatement := `SELECT ` + variantColumns + ` FROM product_variants WHERE id=$1 AND product_id=$2`
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	if err := scanVariant(a.db.QueryRowContext(qc, sqlStatement, variantID, productID), &v); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+		} else {
+			writeJSONError(w, http.StatusInternalServerError, "Gagal mengambil varian")
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	jsoni.NewEncoder(w).Encode(v)
+}
+
+func (a *App) updateVariantHandler(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, err := parseVariantVars(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	v, err := decodeVariant(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	attrs, err := jsoni.Marshal(v.Attributes)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Atribut varian tidak valid")
+		return
+	}
+	v.ID, v.ProductID = variantID, productID
+	sqlStatement := `UPDATE product_variants SET sku=$1, attributes=$2, stock=$3 WHERE id=$4 AND product_id=$5`
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	res, err := a.db.ExecContext(qc, sqlStatement, v.SKU, attrs, v.Stock, variantID, productID)
+	if err != nil {
+		writeVariantWriteError(w, r, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+		return
+	}
+	a.invalidateProductCaches(r.Context(), productID)
+	a.invalidateCategoryStockForProduct(r.Context(), productID)
+	w.Header().Set("Content-Type", "application/json")
+	jsoni.NewEncoder(w).Encode(v)
+}
+
+func (a *App) deleteVariantHandler(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, err := parseVariantVars(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	qc, cancel := withQueryTimeout(r.Context())
+	defer cancel()
+	res, err := a.db.ExecContext(qc, `DELETE FROM product_variants WHERE id=$1 AND product_id=$2`, variantID, productID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Gagal menghapus varian")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+		return
+	}
+	a.invalidateProductCaches(r.Context(), productID)
+	a.invalidateCategoryStockForProduct(r.Context(), productID)
+	w.WriteHeader(http.StatusNoContent)
+}
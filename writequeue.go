@@ -0,0 +1,364 @@
+package main
+
+// Antrean tulis saat failover database (opt-in lewat WRITE_QUEUE_ENABLED).
+//
+// Saat database primer tidak dapat dijangkau, request tulis (POST/PUT/DELETE) tidak ditolak
+// melainkan disimpan di Redis dan dijawab 202 Accepted dengan id operasi. Worker background
+// memutar ulang operasi tersebut melalui router yang sama setelah database pulih.
+// Status dapat dicek di GET /operations/{id}.
+//
+// Konsekuensi konsistensi yang perlu dipahami klien:
+//   - 202 bukan jaminan berhasil: operasi bisa gagal saat diputar ulang (validasi, 404, konflik),
+//     dan hasil akhirnya hanya terlihat di /operations/{id}.
+//   - Read-your-writes tidak berlaku: sampai diputar ulang, GET tidak melihat perubahan ini.
+//   - Antrean bersifat FIFO, tetapi dipakai bersama oleh semua instance; dengan beberapa worker
+//     urutan eksekusi tidak dijamin, dan tulis langsung yang sempat mencapai database bisa
+//     mendahului operasi yang diantrekan.
+//   - Operasi diputar ulang satu per satu tanpa deduplikasi; kirim Idempotency-Key bila
+//     klien juga melakukan retry sendiri.
+//   - Pengiriman at-least-once: operasi dipindah (BLMOVE) ke daftar processing dan baru dihapus
+//     setelah replay selesai. Operasi yang tertinggal di sana karena instance mati (lease-nya
+//     habis) dikembalikan ke antrean, jadi operasi yang sempat tersimpan tepat sebelum crash
+//     bisa diputar ulang dua kali.
+//   - Header yang disimpan (termasuk X-API-Key) ikut tersimpan di Redis selama TTL operasi.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/mux"
+)
+
+const (
+	writeQueueKey           = "operations:queue"
+	writeQueueProcessingKey = "operations:processing"
+	// Lebih lama dari batas waktu route terpanjang (defaultRouteTimeouts), agar operasi yang
+	// masih diputar ulang tidak dianggap yatim oleh instance lain
+	writeQueueLeaseTTL = 15 * time.Minute
+	operationTTL       = 24 * time.Hour
+	writeQueueMaxBody  = 1 << 20
+	dbProbeInterval    = 2 * time.Second
+	dbProbeTimeout     = time.Second
+	writeQueuePollIdle = time.Second
+)
+
+// Header yang ikut disimpan dan dikirim saat operasi diputar ulang
+var queuedHeaders = []string{"Content-Type", "X-API-Key", "If-Match", "Idempotency-Key", "X-Request-ID"}
+
+type queuedOperation struct {
+	ID        string            `json:"id"`
+	Method    string            `json:"method"`
+	URI       string            `json:"uri"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      []byte            `json:"body,omitempty"`
+	Status    string            `json:"status"` // queued, completed, failed
+	CreatedAt JSONTime          `json:"createdAt"`
+	UpdatedAt JSONTime          `json:"updatedAt"`
+
+	ResponseStatus int             `json:"responseStatus,omitempty"`
+	ResponseBody   rawResponseBody `json:"responseBody,omitempty"`
+}
+
+// rawResponseBody menyimpan body respons hasil replay: JSON apa adanya, atau string untuk teks biasa
+type rawResponseBody []byte
+
+func (b rawResponseBody) MarshalJSON() ([]byte, error) {
+	if len(b) == 0 {
+		return []byte("null"), nil
+	}
+	if json.Valid(b) {
+		return b, nil
+	}
+	return jsoni.Marshal(string(b))
+}
+
+func (b *rawResponseBody) UnmarshalJSON(data []byte) error {
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func operationKey(id string) string {
+	return "operation:" + id
+}
+
+// operationLeaseKey ada selama sebuah worker memegang operasi di daftar processing
+func operationLeaseKey(id string) string {
+	return operationKey(id) + ":lease"
+}
+
+type replayKey struct{}
+
+// dbAvailable diperbarui oleh probe background agar middleware tidak perlu ping di setiap request
+var dbAvailable int32 = 1
+
+func (a *App) startDBProbe() {
+	go func() {
+		ticker := time.NewTicker(dbProbeInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			c, cancel := context.WithTimeout(context.Background(), dbProbeTimeout)
+			err := a.db.PingContext(c)
+			cancel()
+			up := int32(1)
+			if err != nil {
+				up = 0
+			}
+			if prev := atomic.SwapInt32(&dbAvailable, up); prev != up {
+				if up == 1 {
+					slog.Info("Database kembali tersedia")
+				} else {
+					slog.Error("Database tidak tersedia", "err", err)
+				}
+			}
+		}
+	}()
+}
+
+func isDBAvailable() bool {
+	return atomic.LoadInt32(&dbAvailable) == 1
+}
+
+// writeQueueMiddleware mengantrekan request tulis ketika database sedang tidak tersedia
+func (a *App) writeQueueMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch:
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if isDBAvailable() || r.Context().Value(replayKey{}) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, writeQueueMaxBody+1))
+		if err != nil || len(body) > writeQueueMaxBody {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, "Body terlalu besar untuk diantrekan")
+			return
+		}
+		now := JSONTime{time.Now().UTC()}
+		op := queuedOperation{
+			ID:        newRequestID(),
+			Method:    r.Method,
+			URI:       r.URL.RequestURI(),
+			Headers:   map[string]string{},
+			Body:      body,
+			Status:    "queued",
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		for _, h := range queuedHeaders {
+			if v := r.Header.Get(h); v != "" {
+				op.Headers[h] = v
+			}
+		}
+		if err := a.saveOperation(r.Context(), &op); err != nil {
+			slog.ErrorContext(r.Context(), "Gagal menyimpan operasi ke antrean", "err", err)
+			writeJSONError(w, http.StatusServiceUnavailable, "Database tidak tersedia")
+			return
+		}
+		if err := a.rdb.RPush(r.Context(), writeQueueKey, op.ID).Err(); err != nil {
+			slog.ErrorContext(r.Context(), "Gagal menambahkan operasi ke antrean", "err", err)
+			writeJSONError(w, http.StatusServiceUnavailable, "Database tidak tersedia")
+			return
+		}
+		slog.WarnContext(r.Context(), "Database tidak tersedia, request diantrekan", "method", op.Method, "uri", op.URI, "operation_id", op.ID)
+
+		w.Header().Set("Location", "/operations/"+op.ID)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		jsoni.NewEncoder(w).Encode(map[string]string{
+			"operationId": op.ID,
+			"status":      op.Status,
+			"statusUrl":   "/operations/" + op.ID,
+		})
+	})
+}
+
+func (a *App) saveOperation(c context.Context, op *queuedOperation) error {
+	data, err := jsoni.Marshal(op)
+	if err != nil {
+		return err
+	}
+	return a.rdb.Set(c, operationKey(op.ID), data, operationTTL).Err()
+}
+
+func (a *App) loadOperation(c context.Context, id string) (*queuedOperation, error) {
+	data, err := a.rdb.Get(c, operationKey(id)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var op queuedOperation
+	if err := jsoni.Unmarshal(data, &op); err != nil {
+		return nil, err
+	}
+	return &op, nil
+}
+
+// startWriteQueueWorker memutar ulang operasi yang diantrekan melalui handler (router) aplikasi.
+// Saat shutdown worker berhenti mengambil operasi baru dan replay yang sedang berjalan
+// ditunggu sampai selesai, sebelum koneksi DB/Redis ditutup.
+func (a *App) startWriteQueueWorker(handler http.Handler) {
+	c, stop := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	registerShutdownHook("write-queue", func(hc context.Context) error {
+		stop()
+		return waitGroupDrain(&wg)(hc)
+	})
+
+	a.requeueOrphanedOperations()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		lastRecovery := time.Now()
+		for c.Err() == nil {
+			if !isDBAvailable() {
+				sleepOrDone(c, dbProbeInterval)
+				continue
+			}
+			// Instance lain yang mati di tengah replay juga meninggalkan operasi di processing
+			if time.Since(lastRecovery) >= writeQueueLeaseTTL {
+				a.requeueOrphanedOperations()
+				lastRecovery = time.Now()
+			}
+			id, err := a.rdb.BLMove(c, writeQueueKey, writeQueueProcessingKey, "LEFT", "RIGHT", writeQueuePollIdle).Result()
+			if err != nil {
+				// redis.Nil: antrean kosong selama writeQueuePollIdle
+				if err != redis.Nil && c.Err() == nil {
+					slog.Warn("Gagal mengambil operasi dari antrean", "err", err)
+					sleepOrDone(c, writeQueuePollIdle)
+				}
+				continue
+			}
+			if err := a.rdb.Set(context.Background(), operationLeaseKey(id), "1", writeQueueLeaseTTL).Err(); err != nil {
+				slog.Warn("Gagal memasang lease operasi", "operation_id", id, "err", err)
+			}
+			a.replayOperation(handler, id)
+		}
+	}()
+}
+
+func sleepOrDone(c context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-c.Done():
+	case <-t.C:
+	}
+}
+
+// requeueOperationScript mengembalikan satu operasi dari processing ke depan antrean bila
+// lease-nya sudah tidak ada. LREM dan LPUSH dalam satu script agar dua instance yang
+// memulihkan bersamaan tidak menggandakan operasi.
+var requeueOperationScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[3]) == 0 and redis.call('LREM', KEYS[1], 1, ARGV[1]) == 1 then
+	redis.call('LPUSH', KEYS[2], ARGV[1])
+	return 1
+end
+return 0`)
+
+// requeueOrphanedOperations memindahkan operasi di processing yang tidak lagi dipegang worker
+// mana pun (instance mati atau dimatikan paksa di tengah replay) kembali ke antrean
+func (a *App) requeueOrphanedOperations() {
+	ids, err := a.rdb.LRange(context.Background(), writeQueueProcessingKey, 0, -1).Result()
+	if err != nil {
+		slog.Error("Gagal membaca operasi yang sedang diproses", "err", err)
+		return
+	}
+	for _, id := range ids {
+		n, err := requeueOperationScript.Run(context.Background(), a.rdb,
+			[]string{writeQueueProcessingKey, writeQueueKey, operationLeaseKey(id)}, id).Int()
+		if err != nil {
+			slog.Error("Gagal mengembalikan operasi ke antrean", "operation_id", id, "err", err)
+			continue
+		}
+		if n == 1 {
+			slog.Warn("Operasi yatim dikembalikan ke antrean", "operation_id", id)
+		}
+	}
+}
+
+// finishOperation melepas operasi dari processing setelah hasilnya tersimpan
+func (a *App) finishOperation(id string) {
+	pipe := a.rdb.TxPipeline()
+	pipe.LRem(context.Background(), writeQueueProcessingKey, 1, id)
+	pipe.Del(context.Background(), operationLeaseKey(id))
+	if _, err := pipe.Exec(context.Background()); err != nil {
+		slog.Error("Gagal melepas operasi dari processing", "operation_id", id, "err", err)
+	}
+}
+
+func (a *App) replayOperation(handler http.Handler, id string) {
+	op, err := a.loadOperation(context.Background(), id)
+	if err != nil {
+		slog.Warn("Operasi tidak dapat dibaca (kedaluwarsa?)", "operation_id", id, "err", err)
+		a.finishOperation(id)
+		return
+	}
+
+	c := context.WithValue(context.Background(), replayKey{}, true)
+	req, err := http.NewRequestWithContext(c, op.Method, op.URI, bytes.NewReader(op.Body))
+	if err != nil {
+		op.Status = "failed"
+		op.ResponseBody = rawResponseBody(err.Error())
+		op.UpdatedAt = JSONTime{time.Now().UTC()}
+		a.saveOperation(context.Background(), op)
+		a.finishOperation(id)
+		return
+	}
+	for k, v := range op.Headers {
+		req.Header.Set(k, v)
+	}
+	rec := &bufferedResponseWriter{header: http.Header{}}
+	handler.ServeHTTP(rec, req)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	// Database kembali hilang di tengah replay: kembalikan ke depan antrean
+	if rec.status >= 500 && !isDBAvailable() {
+		pipe := a.rdb.TxPipeline()
+		pipe.LRem(context.Background(), writeQueueProcessingKey, 1, op.ID)
+		pipe.LPush(context.Background(), writeQueueKey, op.ID)
+		pipe.Del(context.Background(), operationLeaseKey(op.ID))
+		if _, err := pipe.Exec(context.Background()); err != nil {
+			slog.Error("Gagal mengembalikan operasi ke antrean", "operation_id", op.ID, "err", err)
+		}
+		return
+	}
+
+	op.Status = "completed"
+	if rec.status >= 400 {
+		op.Status = "failed"
+	}
+	op.ResponseStatus = rec.status
+	op.ResponseBody = rawResponseBody(bytes.TrimSpace(rec.body.Bytes()))
+	op.UpdatedAt = JSONTime{time.Now().UTC()}
+	if err := a.saveOperation(context.Background(), op); err != nil {
+		slog.Error("Gagal menyimpan hasil operasi", "operation_id", op.ID, "err", err)
+	}
+	// Tulisan sudah ter-commit; dilepas walau hasilnya gagal disimpan agar tidak diputar ulang
+	a.finishOperation(op.ID)
+	slog.Info("Operasi diputar ulang", "operation_id", op.ID, "method", op.Method, "uri", op.URI, "status", rec.status)
+}
+
+// getOperationHandler mengembalikan status operasi yang diantrekan (tanpa header dan body asli)
+func (a *App) getOperationHandler(w http.ResponseWriter, r *http.Request) {
+	op, err := a.loadOperation(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "Data tidak ditemukan")
+		return
+	}
+	op.Headers, op.Body = nil, nil
+	w.Header().Set("Content-Type", "application/json")
+	jsoni.NewEncoder(w).Encode(op)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRequeueOrphanedOperations(t *testing.T) {
+	a, _, _ := newTestApp(t)
+	c := context.Background()
+	a.rdb.RPush(c, writeQueueKey, "baru")
+	a.rdb.RPush(c, writeQueueProcessingKey, "yatim", "dipegang")
+	a.rdb.Set(c, operationLeaseKey("dipegang"), "1", time.Minute)
+
+	a.requeueOrphanedOperations()
+
+	queue := a.rdb.LRange(c, writeQueueKey, 0, -1).Val()
+	processing := a.rdb.LRange(c, writeQueueProcessingKey, 0, -1).Val()
+	if len(queue) != 2 || queue[0] != "yatim" || queue[1] != "baru" {
+		t.Fatalf("antrean %v, ingin [yatim baru]", queue)
+	}
+	if len(processing) != 1 || processing[0] != "dipegang" {
+		t.Fatalf("processing %v, ingin [dipegang]", processing)
+	}
+}
+
+func TestWriteQueueWorkerReplaysAndReleases(t *testing.T) {
+	a, _, _ := newTestApp(t)
+	c := context.Background()
+	op := queuedOperation{ID: "op-1", Method: http.MethodPost, URI: "/products", Status: "queued"}
+	if err := a.saveOperation(context.Background(), &op); err != nil {
+		t.Fatal(err)
+	}
+	a.rdb.RPush(c, writeQueueKey, op.ID)
+
+	replayed := make(chan struct{}, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Selama replay operasi berada di processing dan lease-nya dipegang
+		if n := a.rdb.Exists(c, operationLeaseKey("op-1")).Val(); n != 1 {
+			t.Errorf("lease tidak dipasang selama replay")
+		}
+		w.WriteHeader(http.StatusCreated)
+		replayed <- struct{}{}
+	})
+	a.startWriteQueueWorker(handler)
+	select {
+	case <-replayed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("operasi tidak diputar ulang")
+	}
+	// Hook shutdown menghentikan worker dan menunggu replay yang sedang berjalan
+	runShutdownHooks(5 * time.Second)
+
+	got, err := a.loadOperation(context.Background(), "op-1")
+	if err != nil || got.Status != "completed" || got.ResponseStatus != http.StatusCreated {
+		t.Fatalf("operasi %+v (%v)", got, err)
+	}
+	if n := a.rdb.LLen(c, writeQueueProcessingKey).Val(); n != 0 {
+		t.Fatalf("processing masih berisi %d operasi", n)
+	}
+	if n := a.rdb.Exists(c, operationLeaseKey("op-1")).Val(); n != 0 {
+		t.Fatal("lease tidak dilepas")
+	}
+}